@@ -0,0 +1,386 @@
+/*
+Copyright © 2020 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gosuri/uitable"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"helm.sh/helm/v3/cmd/helm/require"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/cli/output"
+)
+
+const applyDesc = `
+Deploy every release declared in a lincos.yaml file.
+
+Each release is installed when it doesn't exist yet and upgraded otherwise,
+exactly like 'lincos helm deploy' does. A failed release doesn't stop the
+others, a report of all releases is printed at the end.
+
+    releases:
+    - name: api
+      chart: stable/nginx
+      version: ^1.2
+      namespace: web
+      values:
+      - values/api.yaml
+      set:
+      - image.tag=1.2.3
+      wait: true
+      timeout: 10m
+
+Values files are relative to the lincos.yaml file. A release without a
+namespace is deployed to '--namespace', or else to the namespace of its kube
+context, also when an environment switches the kube context.
+
+A release may list the releases it 'needs', it is deployed only after all of
+them were deployed successfully. Releases which don't need each other are
+deployed at the same time, at most '--concurrency' of them. When a release
+fails, the releases which need it are skipped, all others still run.
+
+Environments override the version, namespace, kube context, values files and
+set values of releases. Select one with '--environment':
+
+    environments:
+      staging:
+        kubeContext: staging
+        releases:
+          api:
+            version: 1.3.0
+            values:
+            - values/api-staging.yaml
+
+Values files ending in .gotmpl are rendered as templates first, with
+{{ .Environment.Name }}, {{ .Release.Name }} and {{ .Release.Namespace }}.
+
+Releases may carry labels. Use '--selector' to deploy only the releases
+matching a label selector, every release also has its name as the label
+'name':
+
+    $ lincos apply -l tier=backend
+    $ lincos apply -l 'team in (payments,billing),tier!=frontend'
+
+Releases are marked as managed by the project of the lincos.yaml in their
+description and in the ConfigMap 'lincos-project-<hash>' of their namespace,
+which keeps them managed across failed upgrades and rollbacks. The project is
+the name of the directory of the file unless 'project' is set.
+
+With '--prune', managed releases which are no longer declared are uninstalled
+after all releases were deployed successfully. It requires 'project' to be
+set. Only the kube contexts and namespaces of the selected releases are
+searched. The releases to prune are printed to stderr first and, unless --yes
+is passed, the command asks for confirmation before anything is deployed.
+
+Given a plan made by 'lincos plan', the charts and values stored in the plan
+are deployed instead. Nothing is deployed when a release has another revision
+than when the plan was made or no longer renders the manifests of the plan.
+
+    $ lincos plan --out plan.lincos
+    $ lincos apply plan.lincos
+`
+
+const (
+	applyStatusFailed  = "failed"
+	applyStatusSkipped = "skipped"
+)
+
+type applyOptions struct {
+	lincosFileOptions
+	dryRun      bool
+	concurrency int
+	prune       bool
+	yes         bool
+}
+
+// applyResult is the outcome of deploying one release
+type applyResult struct {
+	Release   string `json:"release"`
+	Namespace string `json:"namespace"`
+	Chart     string `json:"chart"`
+	Version   string `json:"version,omitempty"`
+	Revision  int    `json:"revision,omitempty"`
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+
+	Provenance *provenanceReport `json:"provenance,omitempty"`
+}
+
+func newApplyCmd(in io.Reader, out io.Writer) *cobra.Command {
+	o := &applyOptions{}
+	var outfmt output.Format
+
+	cmd := &cobra.Command{
+		Use:   "apply [PLAN]",
+		Short: "Deploy the releases of a lincos.yaml file or of a plan",
+		Long:  applyDesc,
+		Args:  require.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var results []applyResult
+			var err error
+			if len(args) == 1 {
+				for _, name := range []string{"file", "environment", "selector", "prune"} {
+					if cmd.Flags().Changed(name) {
+						return errors.Errorf("--%s can't be used with a plan, it was applied when the plan was made", name)
+					}
+				}
+				results, err = RunApplyPlan(args[0], o, os.Stderr)
+			} else {
+				results, err = RunApply(o, in, os.Stderr)
+			}
+			if err != nil {
+				return err
+			}
+			if err := outfmt.Write(out, &applyWriter{results}); err != nil {
+				return err
+			}
+			// The report already shows what went wrong
+			cmd.SilenceUsage = true
+			return applyError(results)
+		},
+	}
+
+	f := cmd.Flags()
+	addLincosFileFlags(f, &o.lincosFileOptions)
+	f.BoolVar(&o.dryRun, "dry-run", false, "simulate the deploy of every release")
+	f.IntVar(&o.concurrency, "concurrency", 4, "maximum number of releases deployed at the same time")
+	f.BoolVar(&o.prune, "prune", false, "uninstall managed releases which are no longer declared")
+	f.BoolVarP(&o.yes, "yes", "y", false, "do not ask for confirmation before pruning")
+	bindOutputFlag(cmd, &outfmt)
+	flags := cmd.PersistentFlags()
+	settings.AddFlags(flags)
+
+	return cmd
+}
+
+//Deploy the releases of a lincos.yaml, progress and prompts go to errOut so
+//that the report stays the only output
+func RunApply(o *applyOptions, in io.Reader, errOut io.Writer) ([]applyResult, error) {
+
+	setLogger()
+	if o.concurrency < 1 {
+		return nil, errors.New("--concurrency must be at least 1")
+	}
+	releases, cleanup, err := o.releases()
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+	// Load the configuration before releases are deployed in parallel
+	if _, err := loadConfig(); err != nil {
+		return nil, err
+	}
+
+	var prune []*releaseSpec
+	if o.prune {
+		if prune, err = planPrune(&o.lincosFileOptions, releases); err != nil {
+			return nil, err
+		}
+		if len(prune) > 0 {
+			writePrunePlan(errOut, prune)
+			if !o.dryRun && !o.yes {
+				ok, err := confirm(in, errOut, fmt.Sprintf("Apply and uninstall %d releases?", len(prune)))
+				if err != nil {
+					return nil, err
+				}
+				if !ok {
+					return nil, errors.New("apply aborted")
+				}
+			}
+		}
+	}
+
+	results := applyReleases(releases, o.concurrency, func(r *releaseSpec) applyResult {
+		return applyRelease(r, o.dryRun, errOut)
+	})
+	if len(prune) == 0 {
+		return results, nil
+	}
+
+	// Never prune while the declared releases are not all deployed
+	if err := applyError(results); err != nil {
+		for _, r := range prune {
+			results = append(results, applyResult{
+				Release:   r.Name,
+				Namespace: r.Namespace,
+				Chart:     r.Chart,
+				Version:   r.Version,
+				Status:    applyStatusSkipped,
+				Error:     "not pruned, declared releases did not complete",
+			})
+		}
+		return results, nil
+	}
+	return append(results, pruneReleases(prune, o.dryRun)...), nil
+}
+
+// applyReleases deploys every release once all releases it needs succeeded,
+// running at most concurrency deploys at the same time. Dependents of a
+// failed release are skipped. Results are in the order of releases.
+func applyReleases(releases []*releaseSpec, concurrency int, deploy func(*releaseSpec) applyResult) []applyResult {
+	type releaseRun struct {
+		done   chan struct{}
+		result applyResult
+	}
+	runs := map[string]*releaseRun{}
+	for _, r := range releases {
+		runs[r.Name] = &releaseRun{done: make(chan struct{})}
+	}
+
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for _, r := range releases {
+		wg.Add(1)
+		go func(r *releaseSpec) {
+			defer wg.Done()
+			run := runs[r.Name]
+			defer close(run.done)
+
+			for _, n := range r.Needs {
+				need := runs[n]
+				<-need.done
+				if need.result.Error != "" {
+					debug("Release \"%s\" is skipped, it needs \"%s\"", r.Name, n)
+					run.result = applyResult{
+						Release:   r.Name,
+						Namespace: r.Namespace,
+						Chart:     r.Chart,
+						Status:    applyStatusSkipped,
+						Error:     fmt.Sprintf("waits for release %s which did not complete", n),
+					}
+					return
+				}
+			}
+
+			sem <- struct{}{}
+			run.result = deploy(r)
+			<-sem
+		}(r)
+	}
+	wg.Wait()
+
+	results := make([]applyResult, 0, len(releases))
+	for _, r := range releases {
+		results = append(results, runs[r.Name].result)
+	}
+	return results
+}
+
+// applyRelease deploys a release with its own action configuration, so every
+// release is stored in its own namespace
+func applyRelease(r *releaseSpec, dryRun bool, errOut io.Writer) applyResult {
+	cfg := new(action.Configuration)
+	client, clientUpgrade := r.clients(cfg)
+	client.DryRun = dryRun
+	clientUpgrade.DryRun = dryRun
+
+	result := applyResult{
+		Release:   r.Name,
+		Namespace: r.Namespace,
+		Chart:     r.Chart,
+	}
+	rel, report, err := RunDeploy([]string{r.Name, r.Chart}, cfg, client, clientUpgrade, r.valueOptions(), r.KubeContext, errOut)
+	if err != nil {
+		log.WithTime(time.Now()).WithFields(log.Fields{
+			"release":     r.Name,
+			"chart":       r.Chart,
+			"Namespace":   client.Namespace,
+			"KubeContext": r.KubeContext,
+			"Error":       err,
+		}).Error("Release failed to deploy.")
+		result.Namespace = client.Namespace
+		result.Status = applyStatusFailed
+		result.Error = err.Error()
+		return result
+	}
+
+	if !dryRun && r.project != "" {
+		configMaps, err := projectConfigMaps(cfg, rel.Namespace)
+		if err == nil {
+			err = trackRelease(configMaps, r.project, r.Name, r.Chart)
+		}
+		if err != nil {
+			log.WithTime(time.Now()).WithFields(log.Fields{
+				"release":   r.Name,
+				"Namespace": rel.Namespace,
+				"Error":     err,
+			}).Warn("Release could not be tracked as managed, only its description marks it.")
+		}
+	}
+
+	result.Namespace = rel.Namespace
+	result.Revision = rel.Version
+	result.Provenance = report
+	result.Status = rel.Info.Status.String()
+	if rel.Chart != nil && rel.Chart.Metadata != nil {
+		result.Version = rel.Chart.Metadata.Version
+	}
+	return result
+}
+
+// applyError fails the command when any release failed
+func applyError(results []applyResult) error {
+	failed, skipped := 0, 0
+	for _, r := range results {
+		switch r.Status {
+		case applyStatusFailed:
+			failed++
+		case applyStatusSkipped:
+			skipped++
+		}
+	}
+	switch {
+	case failed > 0 && skipped > 0:
+		return errors.Errorf("%d of %d releases failed, %d skipped", failed, len(results), skipped)
+	case failed > 0:
+		return errors.Errorf("%d of %d releases failed", failed, len(results))
+	}
+	return nil
+}
+
+type applyWriter struct {
+	results []applyResult
+}
+
+func (a *applyWriter) WriteTable(out io.Writer) error {
+	table := uitable.New()
+	table.AddRow("RELEASE", "NAMESPACE", "CHART", "VERSION", "REVISION", "STATUS", "ERROR")
+	for _, r := range a.results {
+		revision := ""
+		if r.Revision > 0 {
+			revision = fmt.Sprint(r.Revision)
+		}
+		table.AddRow(r.Release, r.Namespace, r.Chart, r.Version, revision, r.Status, r.Error)
+	}
+	return output.EncodeTable(out, table)
+}
+
+func (a *applyWriter) WriteJSON(out io.Writer) error {
+	return output.EncodeJSON(out, a.results)
+}
+
+func (a *applyWriter) WriteYAML(out io.Writer) error {
+	return output.EncodeYAML(out, a.results)
+}
@@ -0,0 +1,123 @@
+/*
+Copyright © 2020 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestApplyReleasesNeeds(t *testing.T) {
+	releases := []*releaseSpec{
+		{Name: "web", Needs: []string{"api"}},
+		{Name: "api", Needs: []string{"db"}},
+		{Name: "db"},
+		{Name: "cache"},
+		{Name: "worker", Needs: []string{"cache"}},
+	}
+
+	var mu sync.Mutex
+	deployed := map[string]bool{}
+	results := applyReleases(releases, 2, func(r *releaseSpec) applyResult {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, n := range r.Needs {
+			if !deployed[n] {
+				t.Errorf("release %s deployed before %s", r.Name, n)
+			}
+		}
+		deployed[r.Name] = true
+		return applyResult{Release: r.Name, Status: "deployed"}
+	})
+
+	if len(results) != len(releases) {
+		t.Fatalf("expected %d results, got %d", len(releases), len(results))
+	}
+	for i, r := range releases {
+		if results[i].Release != r.Name {
+			t.Errorf("expected result %d to be %s, got %s", i, r.Name, results[i].Release)
+		}
+		if results[i].Status != "deployed" {
+			t.Errorf("expected %s to be deployed, got %s", r.Name, results[i].Status)
+		}
+	}
+}
+
+func TestApplyReleasesSkipsDependentsOfFailed(t *testing.T) {
+	releases := []*releaseSpec{
+		{Name: "db"},
+		{Name: "api", Needs: []string{"db"}},
+		{Name: "web", Needs: []string{"api"}},
+		{Name: "cache"},
+	}
+
+	results := applyReleases(releases, 4, func(r *releaseSpec) applyResult {
+		if r.Name == "db" {
+			return applyResult{Release: r.Name, Status: applyStatusFailed, Error: "boom"}
+		}
+		return applyResult{Release: r.Name, Status: "deployed"}
+	})
+
+	expected := map[string]string{
+		"db":    applyStatusFailed,
+		"api":   applyStatusSkipped,
+		"web":   applyStatusSkipped,
+		"cache": "deployed",
+	}
+	for _, r := range results {
+		if r.Status != expected[r.Release] {
+			t.Errorf("expected %s to be %s, got %s", r.Release, expected[r.Release], r.Status)
+		}
+	}
+	if err := applyError(results); err == nil || err.Error() != "1 of 4 releases failed, 2 skipped" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestApplyReleasesConcurrency(t *testing.T) {
+	releases := []*releaseSpec{}
+	for _, name := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		releases = append(releases, &releaseSpec{Name: name})
+	}
+
+	for _, concurrency := range []int{1, 3} {
+		var mu sync.Mutex
+		running, max := 0, 0
+		applyReleases(releases, concurrency, func(r *releaseSpec) applyResult {
+			mu.Lock()
+			running++
+			if running > max {
+				max = running
+			}
+			mu.Unlock()
+
+			time.Sleep(10 * time.Millisecond)
+
+			mu.Lock()
+			running--
+			mu.Unlock()
+			return applyResult{Release: r.Name}
+		})
+		if max > concurrency {
+			t.Errorf("expected at most %d deploys at the same time, got %d", concurrency, max)
+		}
+		if max < concurrency {
+			t.Errorf("expected %d deploys at the same time, got %d", concurrency, max)
+		}
+	}
+}
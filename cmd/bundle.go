@@ -0,0 +1,332 @@
+/*
+Copyright © 2020 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+
+	"helm.sh/helm/v3/cmd/helm/require"
+	"helm.sh/helm/v3/pkg/cli/values"
+	"helm.sh/helm/v3/pkg/helmpath"
+)
+
+// Files at the top of every bundle
+const (
+	bundleManifestFile = "bundle.yaml"
+	bundleImagesFile   = "images.txt"
+	bundleChecksumFile = "SHA256SUMS"
+)
+
+var bundleHelp = `
+This command consists of multiple subcommands to work with deployment bundles.
+
+A bundle is a single tarball holding the charts of a set of releases together
+with their dependencies, values files, the container images they use and a
+checksum of every file. Deploy a release from it with
+
+    $ lincos helm deploy RELEASE --bundle bundle.tgz
+`
+
+// bundleManifest describes the releases stored in a bundle
+type bundleManifest struct {
+	Releases []bundleRelease `json:"releases"`
+}
+
+type bundleRelease struct {
+	Name    string   `json:"name"`
+	Chart   string   `json:"chart"`
+	Version string   `json:"version"`
+	Values  []string `json:"values,omitempty"`
+	Images  []string `json:"images,omitempty"`
+}
+
+// bundle is an extracted and verified bundle
+type bundle struct {
+	Dir      string
+	Manifest bundleManifest
+}
+
+func newBundleCmd(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "bundle create [ARGS]",
+		Short: "Create bundles for air-gapped deployments",
+		Long:  bundleHelp,
+		Args:  require.NoArgs,
+	}
+
+	flags := cmd.PersistentFlags()
+	settings.AddFlags(flags)
+
+	cmd.AddCommand(
+		newBundleCreateCmd(out),
+	)
+
+	return cmd
+}
+
+// Release returns the release stored under name
+func (b *bundle) Release(name string) (*bundleRelease, error) {
+	for i := range b.Manifest.Releases {
+		if b.Manifest.Releases[i].Name == name {
+			return &b.Manifest.Releases[i], nil
+		}
+	}
+	return nil, errors.Errorf("release %q not found in bundle", name)
+}
+
+// deployArgs points a deploy at the chart and values stored in the bundle.
+// The values files of the bundle come first so that values given on the
+// command line still override them.
+func (b *bundle) deployArgs(args []string, valueOpts *values.Options) ([]string, error) {
+	if len(args) != 1 {
+		return nil, errors.New("a chart can't be given together with --bundle")
+	}
+
+	r, err := b.Release(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, f := range r.Values {
+		files = append(files, filepath.Join(b.Dir, filepath.FromSlash(f)))
+	}
+	valueOpts.ValueFiles = append(files, valueOpts.ValueFiles...)
+
+	return []string{r.Name, filepath.Join(b.Dir, filepath.FromSlash(r.Chart))}, nil
+}
+
+// openBundle extracts a bundle into the cache and verifies its checksums.
+// Bundles are stored by their own digest, so a bundle is extracted once.
+func openBundle(file string) (*bundle, error) {
+	digest, err := fileDigest(file)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := helmpath.CachePath("bundles", digest)
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		// Extract into a temporary directory first so that an interrupted
+		// extraction never ends up in the cache
+		tmp := dir + ".tmp"
+		os.RemoveAll(tmp)
+		if err := extractBundle(file, tmp); err != nil {
+			os.RemoveAll(tmp)
+			return nil, err
+		}
+		if err := os.Rename(tmp, dir); err != nil {
+			return nil, err
+		}
+	}
+	debug("We use bundle \"%s\" extracted to %s", file, dir)
+
+	if err := verifyBundle(dir); err != nil {
+		return nil, errors.Wrapf(err, "bundle %s", file)
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, bundleManifestFile))
+	if err != nil {
+		return nil, err
+	}
+	b := &bundle{Dir: dir}
+	if err := yaml.UnmarshalStrict(data, &b.Manifest); err != nil {
+		return nil, errors.Wrapf(err, "invalid %s in bundle %s", bundleManifestFile, file)
+	}
+	return b, nil
+}
+
+func extractBundle(file, dir string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return errors.Wrapf(err, "%s is not a bundle", file)
+	}
+	tr := tar.NewReader(zr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		name := path.Clean(hdr.Name)
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return errors.Errorf("illegal file path %q in bundle", hdr.Name)
+		}
+		target := filepath.Join(dir, filepath.FromSlash(name))
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			w, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(w, tr)
+			w.Close()
+			if err != nil {
+				return err
+			}
+		default:
+			return errors.Errorf("unsupported file %q in bundle", hdr.Name)
+		}
+	}
+}
+
+// verifyBundle checks every file of an extracted bundle against SHA256SUMS
+func verifyBundle(dir string) error {
+	sums, err := readChecksums(filepath.Join(dir, bundleChecksumFile))
+	if err != nil {
+		return err
+	}
+
+	err = filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == bundleChecksumFile {
+			return nil
+		}
+
+		want, ok := sums[rel]
+		if !ok {
+			return errors.Errorf("%s is not listed in %s", rel, bundleChecksumFile)
+		}
+		got, err := fileDigest(p)
+		if err != nil {
+			return err
+		}
+		if got != want {
+			return errors.Errorf("checksum mismatch for %s", rel)
+		}
+		delete(sums, rel)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for name := range sums {
+		return errors.Errorf("%s is missing", name)
+	}
+	return nil
+}
+
+func readChecksums(file string) (map[string]string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, errors.Wrapf(err, "missing %s", bundleChecksumFile)
+	}
+	defer f.Close()
+
+	sums := map[string]string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			return nil, errors.Errorf("invalid line %q in %s", scanner.Text(), bundleChecksumFile)
+		}
+		sums[fields[1]] = fields[0]
+	}
+	return sums, scanner.Err()
+}
+
+// bundleWriter writes files into a bundle and records their checksums
+type bundleWriter struct {
+	tw   *tar.Writer
+	sums map[string]string
+}
+
+func (w *bundleWriter) Add(name string, data []byte) error {
+	hdr := &tar.Header{
+		Name: name,
+		Mode: 0644,
+		Size: int64(len(data)),
+	}
+	if err := w.tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	if _, err := w.tw.Write(data); err != nil {
+		return err
+	}
+	w.sums[name] = fmt.Sprintf("%x", sha256.Sum256(data))
+	return nil
+}
+
+// Close writes SHA256SUMS, in the format of sha256sum(1), as the last file
+func (w *bundleWriter) Close() error {
+	var names []string
+	for name := range w.sums {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&sb, "%s  %s\n", w.sums[name], name)
+	}
+	if err := w.Add(bundleChecksumFile, []byte(sb.String())); err != nil {
+		return err
+	}
+	return w.tw.Close()
+}
+
+func fileDigest(file string) (string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
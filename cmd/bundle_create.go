@@ -0,0 +1,337 @@
+/*
+Copyright © 2020 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/cli/values"
+	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/releaseutil"
+)
+
+const bundleCreateDesc = `
+Create a bundle for the given releases, each given as RELEASE=CHART.
+
+Charts are resolved like in 'lincos helm deploy', so repository, oci:// and
+git+ references work as well as local paths. Missing dependencies of local
+chart directories are downloaded first. The charts are rendered client side
+with their values to collect the container images they use.
+
+    $ lincos bundle create db=bitnami/postgresql api=./charts/api \
+        --chart-version db=9.8.1 -f api=values/api.yaml -o platform.tgz
+`
+
+type bundleCreateOptions struct {
+	output        string
+	values        []string
+	chartVersions []string
+	verify        bool
+	keyring       string
+}
+
+func newBundleCreateCmd(out io.Writer) *cobra.Command {
+	o := &bundleCreateOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "create RELEASE=CHART [RELEASE=CHART...]",
+		Short: "Create a bundle of charts, values and images",
+		Long:  bundleCreateDesc,
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := RunBundleCreate(args, o, out); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "Successfully saved bundle to: %s\n", o.output)
+			return nil
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringVarP(&o.output, "output", "o", "bundle.tgz", "file to write the bundle to")
+	f.StringArrayVarP(&o.values, "values", "f", []string{}, "values file of a release as RELEASE=FILE (can specify multiple)")
+	f.StringArrayVar(&o.chartVersions, "chart-version", []string{}, "chart version constraint of a release as RELEASE=VERSION (can specify multiple)")
+	f.BoolVar(&o.verify, "verify", false, "verify the charts and bundle their provenance files")
+	f.StringVar(&o.keyring, "keyring", defaultKeyring(), "location of public keys used for verification")
+	f.BoolVar(&registryPlainHTTP, "plain-http", false, "use insecure HTTP connections for OCI registries")
+
+	return cmd
+}
+
+// Create a bundle for air-gapped deployments
+func RunBundleCreate(args []string, o *bundleCreateOptions, out io.Writer) error {
+
+	setLogger()
+	cfg := new(action.Configuration)
+	if err := initActionConfig(cfg); err != nil {
+		return err
+	}
+
+	var releases []bundleRelease
+	charts := map[string]string{}
+	for _, arg := range args {
+		name, ref, err := splitReleaseArg(arg)
+		if err != nil {
+			return err
+		}
+		if _, ok := charts[name]; ok {
+			return errors.Errorf("release %q is given twice", name)
+		}
+		charts[name] = ref
+		releases = append(releases, bundleRelease{Name: name})
+	}
+
+	valueFiles := map[string][]string{}
+	for _, arg := range o.values {
+		name, file, err := splitReleaseArg(arg)
+		if err != nil {
+			return err
+		}
+		if _, ok := charts[name]; !ok {
+			return errors.Errorf("values given for unknown release %q", name)
+		}
+		valueFiles[name] = append(valueFiles[name], file)
+	}
+
+	chartVersions := map[string]string{}
+	for _, arg := range o.chartVersions {
+		name, version, err := splitReleaseArg(arg)
+		if err != nil {
+			return err
+		}
+		if _, ok := charts[name]; !ok {
+			return errors.Errorf("chart version given for unknown release %q", name)
+		}
+		chartVersions[name] = version
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(o.output), ".bundle-*.tgz")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	zw := gzip.NewWriter(tmp)
+	w := &bundleWriter{tw: tar.NewWriter(zw), sums: map[string]string{}}
+
+	images := map[string]bool{}
+	for i := range releases {
+		r := &releases[i]
+		cpo := &action.ChartPathOptions{Version: chartVersions[r.Name], Verify: o.verify, Keyring: o.keyring}
+		if err := addBundleRelease(w, cfg, r, cpo, charts[r.Name], valueFiles[r.Name], out); err != nil {
+			return errors.Wrapf(err, "release %s", r.Name)
+		}
+		for _, image := range r.Images {
+			images[image] = true
+		}
+	}
+
+	manifest, err := yaml.Marshal(&bundleManifest{Releases: releases})
+	if err != nil {
+		return err
+	}
+	if err := w.Add(bundleManifestFile, manifest); err != nil {
+		return err
+	}
+	var sb strings.Builder
+	for _, image := range sortedKeys(images) {
+		fmt.Fprintln(&sb, image)
+	}
+	if err := w.Add(bundleImagesFile, []byte(sb.String())); err != nil {
+		return err
+	}
+
+	if err := w.Close(); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	// Temporary files are only readable by their owner
+	if err := tmp.Chmod(0644); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), o.output)
+}
+
+// addBundleRelease packages the chart and values of a release into the bundle
+func addBundleRelease(w *bundleWriter, cfg *action.Configuration, r *bundleRelease, cpo *action.ChartPathOptions, ref string, files []string, out io.Writer) error {
+	if cpo.Verify {
+		if err := checkVerifiable(ref, settings.Namespace(), false); err != nil {
+			return err
+		}
+	}
+	cp, _, err := locateChart(cpo, ref)
+	if err != nil {
+		return err
+	}
+	if cpo.Verify {
+		if _, err := verifyChart(cpo, ref, cp, settings.Namespace(), false); err != nil {
+			return err
+		}
+	}
+	debug("We bundle chart \"%s\" from %s", ref, cp)
+
+	ch, err := loadChart(cp, cpo, true, out)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempDir("", "lincos-bundle")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	archive, err := bundleArchive(ch, cp, tmp)
+	if err != nil {
+		return err
+	}
+	data, err := ioutil.ReadFile(archive)
+	if err != nil {
+		return err
+	}
+	r.Chart = path.Join("charts", r.Name, filepath.Base(archive))
+	r.Version = ch.Metadata.Version
+	if err := w.Add(r.Chart, data); err != nil {
+		return err
+	}
+	// Keep the provenance file so that the chart can still be verified
+	if prov, err := ioutil.ReadFile(archive + ".prov"); err == nil {
+		if err := w.Add(r.Chart+".prov", prov); err != nil {
+			return err
+		}
+	}
+
+	for i, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		name := path.Join("values", r.Name, fmt.Sprintf("%d-%s", i, filepath.Base(file)))
+		if err := w.Add(name, data); err != nil {
+			return err
+		}
+		r.Values = append(r.Values, name)
+	}
+
+	vals, err := (&values.Options{ValueFiles: files}).MergeValues(getter.All(settings))
+	if err != nil {
+		return err
+	}
+
+	client := action.NewInstall(cfg)
+	client.DryRun = true
+	client.Replace = true
+	client.ClientOnly = true
+	client.ReleaseName = r.Name
+	client.Namespace = settings.Namespace()
+	rel, err := client.Run(ch, vals)
+	if err != nil {
+		return errors.Wrap(err, "rendering chart")
+	}
+
+	r.Images, err = releaseImages(rel)
+	return err
+}
+
+// bundleArchive returns the chart archive to bundle. Packaged charts are
+// bundled as they are, which keeps their signature valid, chart directories
+// are packaged together with their dependencies into dir.
+func bundleArchive(ch *chart.Chart, cp, dir string) (string, error) {
+	if fi, err := os.Stat(cp); err == nil && !fi.IsDir() {
+		return cp, nil
+	}
+	return chartutil.Save(ch, dir)
+}
+
+// releaseImages returns the container images used by the rendered manifests
+// and hooks of a release
+func releaseImages(rel *release.Release) ([]string, error) {
+	manifests := []string{rel.Manifest}
+	for _, h := range rel.Hooks {
+		manifests = append(manifests, h.Manifest)
+	}
+
+	images := map[string]bool{}
+	for _, m := range manifests {
+		for _, content := range releaseutil.SplitManifests(m) {
+			obj, err := parseObject(content)
+			if err != nil {
+				return nil, err
+			}
+			collectImages(obj, images)
+		}
+	}
+	return sortedKeys(images), nil
+}
+
+func collectImages(v interface{}, images map[string]bool) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if image, ok := value.(string); ok && key == "image" && image != "" {
+				images[image] = true
+				continue
+			}
+			collectImages(value, images)
+		}
+	case []interface{}:
+		for _, value := range v {
+			collectImages(value, images)
+		}
+	}
+}
+
+// splitReleaseArg splits arguments of the form RELEASE=VALUE
+func splitReleaseArg(arg string) (string, string, error) {
+	i := strings.Index(arg, "=")
+	if i <= 0 || i == len(arg)-1 {
+		return "", "", errors.Errorf("%q is not of the form RELEASE=VALUE", arg)
+	}
+	return arg[:i], arg[i+1:], nil
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,180 @@
+/*
+Copyright © 2020 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"helm.sh/helm/v3/cmd/helm/require"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/downloader"
+	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+var chartHelp = `
+This command consists of multiple subcommands to work with charts.
+`
+
+func newChartCmd(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "chart package [ARGS]",
+		Short: "Package and sign charts",
+		Long:  chartHelp,
+		Args:  require.NoArgs,
+	}
+
+	flags := cmd.PersistentFlags()
+	settings.AddFlags(flags)
+
+	cmd.AddCommand(
+		newChartPackageCmd(out),
+	)
+
+	return cmd
+}
+
+// locateChart resolves a chart reference to a local path. Besides the
+// references helm understands it accepts oci://registry/path/chart:version
+// and git+<remote>//<path>?ref=<ref>, for the latter the resolved commit
+// is returned as well. The chart is not verified, with --verify the
+// provenance file of a downloaded chart is fetched for verifyChart.
+func locateChart(c *action.ChartPathOptions, name string) (string, string, error) {
+	switch {
+	case strings.HasPrefix(name, ociScheme):
+		cp, err := pullOCIChart(name, c.Version)
+		return cp, "", err
+	case strings.HasPrefix(name, gitScheme):
+		return checkoutGitChart(name)
+	}
+
+	name = strings.TrimSpace(name)
+	_, statErr := os.Stat(name)
+	if !c.Verify || statErr == nil || filepath.IsAbs(name) || strings.HasPrefix(name, ".") {
+		opts := *c
+		opts.Verify = false
+		cp, err := opts.LocateChart(name, settings)
+		return cp, "", err
+	}
+	cp, err := downloadChart(c, name)
+	return cp, "", err
+}
+
+// downloadChart downloads a chart of a repository with its provenance file,
+// like ChartPathOptions.LocateChart does but leaving verification to the caller
+func downloadChart(c *action.ChartPathOptions, name string) (string, error) {
+	version := strings.TrimSpace(c.Version)
+	dl := downloader.ChartDownloader{
+		Out:     os.Stdout,
+		Verify:  downloader.VerifyLater,
+		Keyring: c.Keyring,
+		Getters: getter.All(settings),
+		Options: []getter.Option{
+			getter.WithBasicAuth(c.Username, c.Password),
+			getter.WithTLSClientConfig(c.CertFile, c.KeyFile, c.CaFile),
+			getter.WithInsecureSkipVerifyTLS(c.InsecureSkipTLSverify),
+		},
+		RepositoryConfig: settings.RepositoryConfig,
+		RepositoryCache:  settings.RepositoryCache,
+	}
+	if c.RepoURL != "" {
+		chartURL, err := repo.FindChartInAuthRepoURL(c.RepoURL, c.Username, c.Password, name, version,
+			c.CertFile, c.KeyFile, c.CaFile, getter.All(settings))
+		if err != nil {
+			return "", err
+		}
+		name = chartURL
+	}
+	if err := os.MkdirAll(settings.RepositoryCache, 0755); err != nil {
+		return "", err
+	}
+
+	filename, _, err := dl.DownloadTo(name, version, settings.RepositoryCache)
+	if err != nil {
+		if settings.Debug {
+			return filename, err
+		}
+		return filename, errors.Errorf("failed to download %q (hint: running `lincos repo update` may help)", name)
+	}
+	return filepath.Abs(filename)
+}
+
+// loadChart loads a located chart and checks that its dependencies are
+// present. With dependencyUpdate, missing dependencies of chart directories
+// are downloaded first, the same way for installs and upgrades.
+func loadChart(cp string, c *action.ChartPathOptions, dependencyUpdate bool, out io.Writer) (*chart.Chart, error) {
+	ch, err := loader.Load(cp)
+	if err != nil {
+		return nil, err
+	}
+	req := ch.Metadata.Dependencies
+	if req == nil {
+		return ch, nil
+	}
+	if err := action.CheckDependencies(ch, req); err != nil {
+		if !dependencyUpdate {
+			return nil, err
+		}
+		// Dependencies of chart archives can't be updated in place
+		if fi, statErr := os.Stat(cp); statErr != nil || !fi.IsDir() {
+			return nil, err
+		}
+		if err := newDependencyManager(cp, c.Keyring, out).Update(); err != nil {
+			return nil, err
+		}
+		if ch, err = loader.Load(cp); err != nil {
+			return nil, errors.Wrap(err, "failed reloading chart after repo update")
+		}
+	}
+	return ch, nil
+}
+
+// locateReleaseChart resolves the chart of a release deployed to namespace.
+// The chart is verified when --verify is given or the provenance policy
+// requires verified charts in namespace, the verification is returned then.
+func locateReleaseChart(c *action.ChartPathOptions, name, namespace string) (string, string, *provenanceReport, error) {
+	required, err := applyProvenancePolicy(c, namespace)
+	if err != nil {
+		return "", "", nil, err
+	}
+	if c.Verify {
+		if err := checkVerifiable(name, namespace, required); err != nil {
+			return "", "", nil, err
+		}
+	}
+
+	cp, commit, err := locateChart(c, name)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	var report *provenanceReport
+	if c.Verify {
+		if report, err = verifyChart(c, name, cp, namespace, required); err != nil {
+			return "", "", nil, err
+		}
+	}
+	return cp, commit, report, nil
+}
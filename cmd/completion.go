@@ -0,0 +1,370 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"helm.sh/helm/v3/cmd/helm/require"
+	"helm.sh/helm/v3/pkg/action"
+)
+
+const completionDesc = `
+Generate autocompletions script for lincos for the specified shell.
+`
+const bashCompDesc = `
+Generate the autocompletion script for lincos for the bash shell.
+
+To load completions in your current shell session:
+$ source <(lincos completion bash)
+
+To load completions for every new session, execute once:
+Linux:
+  $ lincos completion bash > /etc/bash_completion.d/lincos
+MacOS:
+  $ lincos completion bash > /usr/local/etc/bash_completion.d/lincos
+`
+
+const zshCompDesc = `
+Generate the autocompletion script for lincos for the zsh shell.
+
+To load completions in your current shell session:
+$ source <(lincos completion zsh)
+
+To load completions for every new session, execute once:
+$ lincos completion zsh > "${fpath[1]}/_lincos"
+`
+
+const fishCompDesc = `
+Generate the autocompletion script for lincos for the fish shell.
+
+To load completions in your current shell session:
+$ lincos completion fish | source
+
+To load completions for every new session, execute once:
+$ lincos completion fish > ~/.config/fish/completions/lincos.fish
+`
+
+const powershellCompDesc = `
+Generate the autocompletion script for lincos for PowerShell.
+
+Release, chart, version and context names are completed in bash, zsh and fish
+only, PowerShell completes commands and flags.
+
+To load completions in your current shell session:
+PS> lincos completion powershell | Out-String | Invoke-Expression
+
+To load completions for every new session, add the output of the above command
+to your PowerShell profile.
+`
+
+func newCompletionCmd(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "completion bash|zsh|fish|powershell",
+		Short: "generate autocompletions script for the specified shell",
+		Long:  completionDesc,
+		Args:  require.NoArgs,
+	}
+
+	bash := &cobra.Command{
+		Use:                   "bash",
+		Short:                 "generate autocompletions script for bash",
+		Long:                  bashCompDesc,
+		Args:                  require.NoArgs,
+		DisableFlagsInUseLine: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runCompletionBash(out, cmd)
+		},
+	}
+
+	zsh := &cobra.Command{
+		Use:                   "zsh",
+		Short:                 "generate autocompletions script for zsh",
+		Long:                  zshCompDesc,
+		Args:                  require.NoArgs,
+		DisableFlagsInUseLine: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runCompletionZsh(out, cmd)
+		},
+	}
+
+	fish := &cobra.Command{
+		Use:                   "fish",
+		Short:                 "generate autocompletions script for fish",
+		Long:                  fishCompDesc,
+		Args:                  require.NoArgs,
+		DisableFlagsInUseLine: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Root().GenFishCompletion(out, true)
+		},
+	}
+
+	powershell := &cobra.Command{
+		Use:                   "powershell",
+		Short:                 "generate autocompletions script for powershell",
+		Long:                  powershellCompDesc,
+		Args:                  require.NoArgs,
+		DisableFlagsInUseLine: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Root().GenPowerShellCompletion(out)
+		},
+	}
+
+	cmd.AddCommand(bash, zsh, fish, powershell)
+
+	return cmd
+}
+
+func runCompletionBash(out io.Writer, cmd *cobra.Command) error {
+	err := cmd.Root().GenBashCompletion(out)
+
+	// In case the user renamed the lincos binary (e.g., to be able to run
+	// several versions), we hook the new binary name to the completion function
+	if binary := filepath.Base(os.Args[0]); binary != "lincos" {
+		renamedBinaryHook := `
+# Hook the command used to generate the completion script
+# to the lincos completion function to handle the case where
+# the user renamed the lincos binary
+if [[ $(type -t compopt) = "builtin" ]]; then
+    complete -o default -F __start_lincos %[1]s
+else
+    complete -o default -o nospace -F __start_lincos %[1]s
+fi
+`
+		fmt.Fprintf(out, renamedBinaryHook, binary)
+	}
+
+	return err
+}
+
+func runCompletionZsh(out io.Writer, cmd *cobra.Command) error {
+	zshInitialization := `#compdef lincos
+
+__lincos_bash_source() {
+	alias shopt=':'
+	alias _expand=_bash_expand
+	alias _complete=_bash_comp
+	emulate -L sh
+	setopt kshglob noshglob braceexpand
+	source "$@"
+}
+__lincos_type() {
+	# -t is not supported by zsh
+	if [ "$1" == "-t" ]; then
+		shift
+		# fake Bash 4 to disable "complete -o nospace". Instead
+		# "compopt +-o nospace" is used in the code to toggle trailing
+		# spaces. We don't support that, but leave trailing spaces on
+		# all the time
+		if [ "$1" = "__lincos_compopt" ]; then
+			echo builtin
+			return 0
+		fi
+	fi
+	type "$@"
+}
+__lincos_compgen() {
+	local completions w
+	completions=( $(compgen "$@") ) || return $?
+	# filter by given word as prefix
+	while [[ "$1" = -* && "$1" != -- ]]; do
+		shift
+		shift
+	done
+	if [[ "$1" == -- ]]; then
+		shift
+	fi
+	for w in "${completions[@]}"; do
+		if [[ "${w}" = "$1"* ]]; then
+			# Use printf instead of echo because it is possible that
+			# the value to print is -n, which would be interpreted
+			# as a flag to echo
+			printf "%s\n" "${w}"
+		fi
+	done
+}
+__lincos_compopt() {
+	true # don't do anything. Not supported by bashcompinit in zsh
+}
+__lincos_ltrim_colon_completions()
+{
+	if [[ "$1" == *:* && "$COMP_WORDBREAKS" == *:* ]]; then
+		# Remove colon-word prefix from COMPREPLY items
+		local colon_word=${1%${1##*:}}
+		local i=${#COMPREPLY[*]}
+		while [[ $((--i)) -ge 0 ]]; do
+			COMPREPLY[$i]=${COMPREPLY[$i]#"$colon_word"}
+		done
+	fi
+}
+__lincos_get_comp_words_by_ref() {
+	cur="${COMP_WORDS[COMP_CWORD]}"
+	prev="${COMP_WORDS[${COMP_CWORD}-1]}"
+	words=("${COMP_WORDS[@]}")
+	cword=("${COMP_CWORD[@]}")
+}
+__lincos_filedir() {
+	local RET OLD_IFS w qw
+	__debug "_filedir $@ cur=$cur"
+	if [[ "$1" = \~* ]]; then
+		# somehow does not work. Maybe, zsh does not call this at all
+		eval echo "$1"
+		return 0
+	fi
+	OLD_IFS="$IFS"
+	IFS=$'\n'
+	if [ "$1" = "-d" ]; then
+		shift
+		RET=( $(compgen -d) )
+	else
+		RET=( $(compgen -f) )
+	fi
+	IFS="$OLD_IFS"
+	IFS="," __debug "RET=${RET[@]} len=${#RET[@]}"
+	for w in ${RET[@]}; do
+		if [[ ! "${w}" = "${cur}"* ]]; then
+			continue
+		fi
+		if eval "[[ \"\${w}\" = *.$1 || -d \"\${w}\" ]]"; then
+			qw="$(__lincos_quote "${w}")"
+			if [ -d "${w}" ]; then
+				COMPREPLY+=("${qw}/")
+			else
+				COMPREPLY+=("${qw}")
+			fi
+		fi
+	done
+}
+__lincos_quote() {
+	if [[ $1 == \'* || $1 == \"* ]]; then
+		# Leave out first character
+		printf %q "${1:1}"
+	else
+		printf %q "$1"
+	fi
+}
+autoload -U +X bashcompinit && bashcompinit
+# use word boundary patterns for BSD or GNU sed
+LWORD='[[:<:]]'
+RWORD='[[:>:]]'
+if sed --help 2>&1 | grep -q 'GNU\|BusyBox'; then
+	LWORD='\<'
+	RWORD='\>'
+fi
+__lincos_convert_bash_to_zsh() {
+	sed \
+	-e 's/declare -F/whence -w/' \
+	-e 's/_get_comp_words_by_ref "\$@"/_get_comp_words_by_ref "\$*"/' \
+	-e 's/local \([a-zA-Z0-9_]*\)=/local \1; \1=/' \
+	-e 's/flags+=("\(--.*\)=")/flags+=("\1"); two_word_flags+=("\1")/' \
+	-e 's/must_have_one_flag+=("\(--.*\)=")/must_have_one_flag+=("\1")/' \
+	-e "s/${LWORD}_filedir${RWORD}/__lincos_filedir/g" \
+	-e "s/${LWORD}_get_comp_words_by_ref${RWORD}/__lincos_get_comp_words_by_ref/g" \
+	-e "s/${LWORD}__ltrim_colon_completions${RWORD}/__lincos_ltrim_colon_completions/g" \
+	-e "s/${LWORD}compgen${RWORD}/__lincos_compgen/g" \
+	-e "s/${LWORD}compopt${RWORD}/__lincos_compopt/g" \
+	-e "s/${LWORD}declare${RWORD}/builtin declare/g" \
+	-e "s/\\\$(type${RWORD}/\$(__lincos_type/g" \
+	-e 's/aliashash\["\(.\{1,\}\)"\]/aliashash[\1]/g' \
+	-e 's/FUNCNAME/funcstack/g' \
+	<<'BASH_COMPLETION_EOF'
+`
+	out.Write([]byte(zshInitialization))
+
+	runCompletionBash(out, cmd)
+
+	zshTail := `
+BASH_COMPLETION_EOF
+}
+__lincos_bash_source <(__lincos_convert_bash_to_zsh)
+`
+	out.Write([]byte(zshTail))
+	return nil
+}
+
+// registerSettingsCompletion completes --kube-context and --namespace for
+// every command carrying its own copy of the global settings flags
+func registerSettingsCompletion(cmd *cobra.Command) {
+	if cmd.PersistentFlags().Lookup("kube-context") != nil {
+		if err := cmd.RegisterFlagCompletionFunc("kube-context", compKubeContexts); err != nil {
+			log.Fatal(err)
+		}
+		if err := cmd.RegisterFlagCompletionFunc("namespace", compNamespaces); err != nil {
+			log.Fatal(err)
+		}
+	}
+	for _, c := range cmd.Commands() {
+		registerSettingsCompletion(c)
+	}
+}
+
+// compKubeContexts provides dynamic auto-completion for the contexts of the kubeconfig
+func compKubeContexts(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	cobra.CompDebugln("About to get the different kube-contexts", settings.Debug)
+
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if len(settings.KubeConfig) > 0 {
+		loadingRules = &clientcmd.ClientConfigLoadingRules{ExplicitPath: settings.KubeConfig}
+	}
+	if config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		loadingRules,
+		&clientcmd.ConfigOverrides{}).RawConfig(); err == nil {
+		ctxs := []string{}
+		for name := range config.Contexts {
+			if strings.HasPrefix(name, toComplete) {
+				ctxs = append(ctxs, name)
+			}
+		}
+		return ctxs, cobra.ShellCompDirectiveNoFileComp
+	}
+	return nil, cobra.ShellCompDirectiveNoFileComp
+}
+
+// compNamespaces provides dynamic auto-completion for the namespaces of the cluster
+func compNamespaces(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	cfg := new(action.Configuration)
+	if err := initActionConfig(cfg); err != nil {
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+	if client, err := cfg.KubernetesClientSet(); err == nil {
+		// Choose a long enough timeout that the user notices somethings is not working
+		// but short enough that the user is not made to wait very long
+		to := int64(3)
+		cobra.CompDebugln(fmt.Sprintf("About to call kube client for namespaces with timeout of: %d", to), settings.Debug)
+
+		nsNames := []string{}
+		if namespaces, err := client.CoreV1().Namespaces().List(context.Background(), metav1.ListOptions{TimeoutSeconds: &to}); err == nil {
+			for _, ns := range namespaces.Items {
+				if strings.HasPrefix(ns.Name, toComplete) {
+					nsNames = append(nsNames, ns.Name)
+				}
+			}
+			return nsNames, cobra.ShellCompDirectiveNoFileComp
+		}
+	}
+	return nil, cobra.ShellCompDirectiveDefault
+}
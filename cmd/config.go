@@ -0,0 +1,85 @@
+/*
+Copyright © 2020 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+
+	"helm.sh/helm/v3/pkg/helmpath"
+)
+
+// lincosConfig is the configuration shared by all lincos commands. It is read
+// from $LINCOS_CONFIG or from lincos/config.yaml in the helm config home:
+//
+//	provenance:
+//	  keyring: /etc/lincos/pubring.gpg
+//	  requireVerified:
+//	  - prod
+//	  - payments-*
+type lincosConfig struct {
+	Provenance provenancePolicy `json:"provenance"`
+}
+
+// provenancePolicy lists the namespaces which only accept charts with a
+// verified provenance file. Namespaces may be given as shell patterns.
+type provenancePolicy struct {
+	Keyring         string   `json:"keyring,omitempty"`
+	RequireVerified []string `json:"requireVerified,omitempty"`
+}
+
+var config *lincosConfig
+
+func configFile() string {
+	if v, ok := os.LookupEnv("LINCOS_CONFIG"); ok {
+		return v
+	}
+	return helmpath.ConfigPath("lincos", "config.yaml")
+}
+
+// loadConfig reads the lincos configuration once, a missing file is an
+// empty configuration
+func loadConfig() (*lincosConfig, error) {
+	if config != nil {
+		return config, nil
+	}
+
+	c := &lincosConfig{}
+	data, err := ioutil.ReadFile(configFile())
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err := yaml.UnmarshalStrict(data, c); err != nil {
+		return nil, errors.Wrapf(err, "invalid lincos configuration %s", configFile())
+	}
+	config = c
+	return config, nil
+}
+
+// RequiresVerification reports whether charts deployed to namespace must be verified
+func (p *provenancePolicy) RequiresVerification(namespace string) bool {
+	for _, pattern := range p.RequireVerified {
+		if ok, _ := path.Match(pattern, namespace); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,145 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"io"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"helm.sh/helm/v3/cmd/helm/require"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/downloader"
+	"helm.sh/helm/v3/pkg/getter"
+)
+
+const dependencyDesc = `
+Manage the dependencies of a chart.
+
+Helm charts store their dependencies in 'charts/'. For chart developers, it is
+often easier to manage dependencies in 'Chart.yaml' which declares all
+dependencies.
+
+The dependency commands operate on that file, making it easy to synchronize
+between the desired dependencies and the actual dependencies stored in the
+'charts/' directory.
+
+For example, this Chart.yaml declares two dependencies:
+
+    # Chart.yaml
+    dependencies:
+    - name: nginx
+      version: "1.2.3"
+      repository: "https://example.com/charts"
+    - name: memcached
+      version: "3.2.1"
+      repository: "https://another.example.com/charts"
+
+
+The 'name' should be the name of a chart, where that name must match the name
+in that chart's 'Chart.yaml' file.
+
+The 'version' field should contain a semantic version or version range.
+
+The 'repository' URL should point to a Chart Repository. Helm expects that by
+appending '/index.yaml' to the URL, it should be able to retrieve the chart
+repository's index. Note: 'repository' can be an alias. The alias must start
+with 'alias:' or '@'.
+
+Starting from 2.2.0, repository can be defined as the path to the directory of
+the dependency charts stored locally. The path should start with a prefix of
+"file://". For example,
+
+    # Chart.yaml
+    dependencies:
+    - name: nginx
+      version: "1.2.3"
+      repository: "file://../dependency_chart/nginx"
+
+If the dependency chart is retrieved locally, it is not required to have the
+repository added to helm by "lincos repo add". Version matching is also supported
+for this case.
+`
+
+const dependencyListDesc = `
+List all of the dependencies declared in a chart.
+
+This can take chart archives and chart directories as input. It will not alter
+the contents of a chart.
+
+This will produce an error if the chart cannot be loaded.
+`
+
+func newDependencyCmd(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "dependency update|build|list",
+		Aliases: []string{"dep", "dependencies"},
+		Short:   "Manage a chart's dependencies",
+		Long:    dependencyDesc,
+		Args:    require.NoArgs,
+	}
+
+	flags := cmd.PersistentFlags()
+	settings.AddFlags(flags)
+
+	cmd.AddCommand(
+		newDependencyListCmd(out),
+		newDependencyUpdateCmd(out),
+		newDependencyBuildCmd(out),
+	)
+
+	return cmd
+}
+
+func newDependencyListCmd(out io.Writer) *cobra.Command {
+	client := action.NewDependency()
+
+	cmd := &cobra.Command{
+		Use:     "list CHART",
+		Aliases: []string{"ls"},
+		Short:   "List the dependencies for the given chart",
+		Long:    dependencyListDesc,
+		Args:    require.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return client.List(chartPathArg(args), out)
+		},
+	}
+	return cmd
+}
+
+// chartPathArg returns the chart given on the command line, defaulting to
+// the current directory
+func chartPathArg(args []string) string {
+	if len(args) > 0 {
+		return filepath.Clean(args[0])
+	}
+	return "."
+}
+
+// newDependencyManager creates a dependency manager for the chart at chartPath
+func newDependencyManager(chartPath, keyring string, out io.Writer) *downloader.Manager {
+	return &downloader.Manager{
+		Out:              out,
+		ChartPath:        chartPath,
+		Keyring:          keyring,
+		Getters:          getter.All(settings),
+		RepositoryConfig: settings.RepositoryConfig,
+		RepositoryCache:  settings.RepositoryCache,
+		Debug:            settings.Debug,
+	}
+}
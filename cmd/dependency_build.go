@@ -0,0 +1,69 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"helm.sh/helm/v3/cmd/helm/require"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/downloader"
+)
+
+const dependencyBuildDesc = `
+Build out the charts/ directory from the Chart.lock file.
+
+Build is used to reconstruct a chart's dependencies to the state specified in
+the lock file. This will not re-negotiate dependencies, as 'lincos dependency update'
+does.
+
+If no lock file is found, 'lincos dependency build' will mirror the behavior
+of 'lincos dependency update'.
+`
+
+func newDependencyBuildCmd(out io.Writer) *cobra.Command {
+	client := action.NewDependency()
+
+	cmd := &cobra.Command{
+		Use:   "build CHART",
+		Short: "Rebuild the charts/ directory based on the Chart.lock file",
+		Long:  dependencyBuildDesc,
+		Args:  require.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunDependencyBuild(chartPathArg(args), client, out)
+		},
+	}
+
+	f := cmd.Flags()
+	f.BoolVar(&client.Verify, "verify", false, "verify the packages against signatures")
+	f.StringVar(&client.Keyring, "keyring", defaultKeyring(), "keyring containing public keys")
+
+	return cmd
+}
+
+//Build the dependencies of a chart from its lock file
+func RunDependencyBuild(chartPath string, client *action.Dependency, out io.Writer) error {
+
+	setLogger()
+	man := newDependencyManager(chartPath, client.Keyring, out)
+	if client.Verify {
+		man.Verify = downloader.VerifyIfPossible
+	}
+	return man.Build()
+}
@@ -40,16 +40,31 @@ type statusPrinter struct {
 	release         *release.Release
 	debug           bool
 	showDescription bool
+	provenance      *provenanceReport
+}
+
+// verifiedRelease adds the provenance of the deployed chart to the release
+type verifiedRelease struct {
+	*release.Release
+	Provenance *provenanceReport `json:"provenance"`
+}
+
+// encoded returns what the JSON and YAML output show
+func (s *statusPrinter) encoded() interface{} {
+	if s.provenance == nil {
+		return s.release
+	}
+	return &verifiedRelease{s.release, s.provenance}
 }
 
 func (s *statusPrinter) WriteJSON(out io.Writer) error {
 	debug("RunDeploy: WriteJSON")
-	return output.EncodeJSON(out, s.release)
+	return output.EncodeJSON(out, s.encoded())
 }
 
 func (s *statusPrinter) WriteYAML(out io.Writer) error {
 	debug("RunDeploy: WriteYAML")
-	return output.EncodeYAML(out, s.release)
+	return output.EncodeYAML(out, s.encoded())
 }
 
 func (s *statusPrinter) WriteTable(out io.Writer) error {
@@ -67,6 +82,9 @@ func (s *statusPrinter) WriteTable(out io.Writer) error {
 	if s.showDescription {
 		fmt.Fprintf(out, "DESCRIPTION: %s\n", s.release.Info.Description)
 	}
+	if s.provenance != nil {
+		s.provenance.writeTable(out)
+	}
 
 	executions := executionsByHookEvent(s.release)
 	if tests, ok := executions[release.HookTest]; !ok || len(tests) == 0 {
@@ -140,25 +158,78 @@ func executionsByHookEvent(rel *release.Release) map[release.HookEvent][]*releas
 func newDeployCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 	clientUpgrade := action.NewUpgrade(cfg)
 	client := action.NewInstall(cfg)
+	testClient := action.NewReleaseTesting(cfg)
 	var outfmt output.Format
+	var runTests bool
+	var bundleFile string
+	var locked bool
+	var lockFile string
 	cmd := &cobra.Command{
 		Use: "deploy [release name] [chart path|chart name]",
 		//PreRun: Valid,
-		Args:  require.MinimumNArgs(1),
-		Short: "Run Deploy of helm commands",
+		Args:              require.MinimumNArgs(1),
+		ValidArgsFunction: compReleaseChartArgs(cfg),
+		Short:             "Run Deploy of helm commands",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			rel, err := RunDeploy(args, cfg, client, clientUpgrade, valueOpts, out)
+			clearDefaultKeyring(cmd, &clientUpgrade.ChartPathOptions)
+			if bundleFile != "" {
+				b, err := openBundle(bundleFile)
+				if err != nil {
+					return err
+				}
+				if args, err = b.deployArgs(args, valueOpts); err != nil {
+					return err
+				}
+				// Everything comes from the bundle, never reach out to a repository
+				client.DependencyUpdate = false
+				clientUpgrade.ChartPathOptions = action.ChartPathOptions{
+					Verify:  clientUpgrade.Verify,
+					Keyring: clientUpgrade.Keyring,
+				}
+			}
+
+			if locked {
+				// The checked chart is deployed, it must come with its
+				// provenance file when the namespace requires verification
+				_, namespace := kubeConfig("", client.Namespace)
+				if _, err := applyProvenancePolicy(&clientUpgrade.ChartPathOptions, namespace); err != nil {
+					return err
+				}
+				var err error
+				if args, err = checkLockedChart(lockFile, args, &clientUpgrade.ChartPathOptions); err != nil {
+					return err
+				}
+			}
+
+			rel, report, err := RunDeploy(args, cfg, client, clientUpgrade, valueOpts, "", out)
 			if err != nil {
 				return err
 			}
-			return outfmt.Write(out, &statusPrinter{rel, settings.Debug, false})
+
+			var testErr error
+			if runTests && !client.DryRun {
+				testClient.Timeout = client.Timeout
+				if rel, testErr = testRelease(testClient, rel.Name); rel == nil {
+					return testErr
+				}
+			}
+
+			if err := outfmt.Write(out, &statusPrinter{rel, settings.Debug, false, report}); err != nil {
+				return err
+			}
+			return testErr
 		},
 	}
 
 	addInstallFlags(cmd.Flags(), client)
 	addUpgradeFlags(cmd.Flags(), clientUpgrade)
 	addChartPathOptionsFlags(cmd.Flags(), &clientUpgrade.ChartPathOptions)
+	bindVersionCompletion(cmd, 1)
 	addValueOptionsFlags(cmd.Flags(), valueOpts)
+	cmd.Flags().StringVar(&bundleFile, "bundle", "", "deploy the release from a bundle created by 'lincos bundle create'")
+	cmd.Flags().BoolVar(&locked, "locked", false, "fail unless the chart resolves exactly as recorded in the lock file")
+	cmd.Flags().StringVar(&lockFile, "lock-file", defaultLockFile, "path to the lock file used with --locked")
+	cmd.Flags().BoolVar(&runTests, "run-tests", false, "run the tests of the release after a successful install or upgrade")
 	bindOutputFlag(cmd, &outfmt)
 	bindPostRenderFlag(cmd, &client.PostRenderer)
 	flags := cmd.PersistentFlags()
@@ -167,28 +238,33 @@ func newDeployCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
 	return cmd
 }
 
-func RunDeploy(args []string, cfg *action.Configuration, client *action.Install, clientUpgrade *action.Upgrade, valueOpts *values.Options, out io.Writer) (*release.Release, error) {
+func RunDeploy(args []string, cfg *action.Configuration, client *action.Install, clientUpgrade *action.Upgrade, valueOpts *values.Options, kubeContext string, out io.Writer) (*release.Release, *provenanceReport, error) {
 
 	setLogger()
 	//client.Version = clientUpgrade.Version
 	//client.RepoURL = clientUpgrade.RepoURL
 	addChartPathOptionsFlagsInstall(client, clientUpgrade)
 	debug("client.ChartPathOptions: \"%s\"", &client.ChartPathOptions)
-	if err := cfg.Init(settings.RESTClientGetter(), settings.Namespace(), os.Getenv("HELM_DRIVER"), log.Printf); err != nil {
-		log.Printf("%+v", err)
-		os.Exit(1)
+	// Releases of a lincos.yaml carry their own kube context and namespace
+	kubeCfg, namespace := kubeConfig(kubeContext, client.Namespace)
+	if kubeContext == "" {
+		kubeContext = settings.KubeContext
+	}
+	client.Namespace = namespace
+	clientUpgrade.Namespace = namespace
+	if err := cfg.Init(kubeCfg, namespace, os.Getenv("HELM_DRIVER"), log.Printf); err != nil {
+		return nil, nil, err
 	}
 
 	name, chart, err := client.NameAndChart(args)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	debug("Chart name: \"%s\"", chart)
 
 	statusHelmChart, err := NewStatus(cfg, name)
 	if err != nil {
-		log.Error(err)
-		os.Exit(1)
+		return nil, nil, err
 	}
 	infoStatusResult, _ := statusHelmChart.InfoStatus()
 
@@ -197,31 +273,29 @@ func RunDeploy(args []string, cfg *action.Configuration, client *action.Install,
 			"chart":       chart,
 			"status":      infoStatusResult,
 			"Error":       err,
-			"Namespace":   settings.Namespace(),
-			"KubeContext": settings.KubeContext,
+			"Namespace":   namespace,
+			"KubeContext": kubeContext,
 		}).Info("Chart isn't deployed we will install now.")
 
-		installHelmChart, err := RunInstall(client, cfg, name, chart, valueOpts, out)
+		installHelmChart, report, err := RunInstall(client, cfg, name, chart, valueOpts, out)
 		if err != nil {
-			log.Error(err)
-			os.Exit(1)
+			return nil, nil, err
 		}
 
 		//debug("Install: %s", installHelmChart)
 
-		return installHelmChart, nil
+		return installHelmChart, report, nil
 	}
 
 	debug("To check if chart exists: \"%+v\"", infoStatusResult.Info.Status)
 
-	upgradeHelmChart, err := RunUpgrade(clientUpgrade, cfg, name, chart, valueOpts, out)
+	upgradeHelmChart, report, err := RunUpgrade(clientUpgrade, cfg, name, chart, valueOpts, client.DependencyUpdate, out)
 	if err != nil {
-		log.Error(err)
-		os.Exit(1)
+		return nil, nil, err
 	}
 
 	//debug("Upgrade: %s", upgradeHelmChart)
 
-	return upgradeHelmChart, nil
+	return upgradeHelmChart, report, nil
 
 }
@@ -0,0 +1,208 @@
+/*
+Copyright © 2020 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"helm.sh/helm/v3/cmd/helm/require"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/cli/output"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage/driver"
+)
+
+const destroyDesc = `
+Uninstall the releases of a lincos.yaml file.
+
+Releases are uninstalled in the reverse order of their needs: a release is
+only uninstalled after all releases which need it are gone. Releases which
+are not installed are left alone.
+
+Unless --yes is passed, the command asks for confirmation before anything is
+deleted. Use '--environment' and '--selector' like with 'lincos apply':
+
+    $ lincos destroy -e staging -l tier=frontend
+`
+
+const (
+	destroyStatusUninstalled  = "uninstalled"
+	destroyStatusDryRun       = "would be uninstalled"
+	destroyStatusNotInstalled = "not installed"
+)
+
+type destroyOptions struct {
+	lincosFileOptions
+	uninstall   action.Uninstall
+	concurrency int
+	yes         bool
+}
+
+func newDestroyCmd(in io.Reader, out io.Writer) *cobra.Command {
+	o := &destroyOptions{}
+	var outfmt output.Format
+
+	cmd := &cobra.Command{
+		Use:   "destroy",
+		Short: "Uninstall the releases of a lincos.yaml file",
+		Long:  destroyDesc,
+		Args:  require.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			results, err := RunDestroy(o, in, os.Stderr)
+			if err != nil {
+				return err
+			}
+			if err := outfmt.Write(out, &applyWriter{results}); err != nil {
+				return err
+			}
+			// The report already shows what went wrong
+			cmd.SilenceUsage = true
+			return applyError(results)
+		},
+	}
+
+	f := cmd.Flags()
+	addLincosFileFlags(f, &o.lincosFileOptions)
+	addUninstallFlags(f, &o.uninstall)
+	f.IntVar(&o.concurrency, "concurrency", 4, "maximum number of releases uninstalled at the same time")
+	f.BoolVarP(&o.yes, "yes", "y", false, "do not ask for confirmation before uninstalling")
+	bindOutputFlag(cmd, &outfmt)
+	flags := cmd.PersistentFlags()
+	settings.AddFlags(flags)
+
+	return cmd
+}
+
+//Uninstall the releases of a lincos.yaml, the prompt goes to errOut so that
+//the report stays the only output
+func RunDestroy(o *destroyOptions, in io.Reader, errOut io.Writer) ([]applyResult, error) {
+
+	setLogger()
+	if o.concurrency < 1 {
+		return nil, errors.New("--concurrency must be at least 1")
+	}
+	releases, cleanup, err := o.releases()
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	if !o.uninstall.DryRun && !o.yes {
+		names := make([]string, 0, len(releases))
+		for _, r := range releases {
+			names = append(names, r.Name)
+		}
+		ok, err := confirm(in, errOut, fmt.Sprintf("Uninstall releases %s?", strings.Join(names, ", ")))
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, errors.New("destroy aborted")
+		}
+	}
+	if _, err := loadConfig(); err != nil {
+		return nil, err
+	}
+
+	return applyReleases(reverseNeeds(releases), o.concurrency, func(r *releaseSpec) applyResult {
+		return destroyRelease(r, &o.uninstall)
+	}), nil
+}
+
+// reverseNeeds turns needs around, so that releases are handled after all
+// releases which need them
+func reverseNeeds(releases []*releaseSpec) []*releaseSpec {
+	neededBy := map[string][]string{}
+	for _, r := range releases {
+		for _, n := range r.Needs {
+			neededBy[n] = append(neededBy[n], r.Name)
+		}
+	}
+	reversed := make([]*releaseSpec, 0, len(releases))
+	for _, r := range releases {
+		rs := *r
+		rs.Needs = neededBy[r.Name]
+		reversed = append(reversed, &rs)
+	}
+	return reversed
+}
+
+// destroyRelease uninstalls a release with the options of the command
+func destroyRelease(r *releaseSpec, opts *action.Uninstall) applyResult {
+	result := applyResult{
+		Release:   r.Name,
+		Namespace: r.Namespace,
+		Chart:     r.Chart,
+	}
+	cfg, namespace, err := r.actionConfig()
+	if err == nil {
+		result.Namespace = namespace
+		client := action.NewUninstall(cfg)
+		client.DryRun = opts.DryRun
+		client.DisableHooks = opts.DisableHooks
+		client.KeepHistory = opts.KeepHistory
+		client.Timeout = opts.Timeout
+		debug("We uninstall release \"%s\" from namespace \"%s\"", r.Name, namespace)
+
+		var res *release.UninstallReleaseResponse
+		res, err = client.Run(r.Name)
+		if (err == nil || errors.Cause(err) == driver.ErrReleaseNotFound) && !opts.DryRun && r.project != "" {
+			configMaps, err := projectConfigMaps(cfg, namespace)
+			if err == nil {
+				err = untrackRelease(configMaps, r.project, r.Name)
+			}
+			if err != nil {
+				debug("Release \"%s\" could not be untracked: %s", r.Name, err)
+			}
+		}
+		switch {
+		case errors.Cause(err) == driver.ErrReleaseNotFound:
+			result.Status = destroyStatusNotInstalled
+			return result
+		case err == nil:
+			result.Status = destroyStatusUninstalled
+			if opts.DryRun {
+				result.Status = destroyStatusDryRun
+			}
+			if res != nil && res.Release != nil {
+				result.Revision = res.Release.Version
+				if res.Release.Chart != nil && res.Release.Chart.Metadata != nil {
+					result.Version = res.Release.Chart.Metadata.Version
+				}
+			}
+			return result
+		}
+	}
+
+	log.WithTime(time.Now()).WithFields(log.Fields{
+		"release":     r.Name,
+		"Namespace":   result.Namespace,
+		"KubeContext": r.KubeContext,
+		"Error":       err,
+	}).Error("Release failed to uninstall.")
+	result.Status = applyStatusFailed
+	result.Error = err.Error()
+	return result
+}
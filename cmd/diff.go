@@ -0,0 +1,286 @@
+/*
+Copyright © 2020 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/pkg/errors"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/spf13/cobra"
+	"helm.sh/helm/v3/cmd/helm/require"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/cli/values"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/releaseutil"
+	"sigs.k8s.io/yaml"
+)
+
+const diffDesc = `
+This command previews an upgrade of a release.
+
+The chart is rendered as a dry-run upgrade and every Kubernetes object of the
+proposed manifest is compared with the deployed one. Values of Secrets are
+masked. With '--detailed-exitcode' the command exits with code 2 when there
+are changes.
+`
+
+const redacted = "(redacted)"
+
+type diffOptions struct {
+	context          int
+	noColor          bool
+	detailedExitcode bool
+	dependencyUpdate bool
+}
+
+func newDiffCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
+	clientUpgrade := action.NewUpgrade(cfg)
+	valueOpts := &values.Options{}
+	opts := &diffOptions{}
+	cmd := &cobra.Command{
+		Use:               "diff [release name] [chart path|chart name]",
+		Short:             "Preview the changes of an upgrade",
+		Long:              diffDesc,
+		Args:              require.ExactArgs(2),
+		ValidArgsFunction: compReleaseChartArgs(cfg),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			clearDefaultKeyring(cmd, &clientUpgrade.ChartPathOptions)
+			changed, err := RunDiff(args, cfg, clientUpgrade, valueOpts, opts, out)
+			if err != nil {
+				return err
+			}
+			if changed && opts.detailedExitcode {
+				cmd.SilenceErrors = true
+				cmd.SilenceUsage = true
+				return pluginError{errors.New("release has changes"), 2}
+			}
+			return nil
+		},
+	}
+
+	f := cmd.Flags()
+	addChartPathOptionsFlags(f, &clientUpgrade.ChartPathOptions)
+	bindVersionCompletion(cmd, 1)
+	addValueOptionsFlags(f, valueOpts)
+	f.BoolVar(&clientUpgrade.ResetValues, "reset-values", false, "when upgrading, reset the values to the ones built into the chart")
+	f.BoolVar(&clientUpgrade.ReuseValues, "reuse-values", false, "when upgrading, reuse the last release's values and merge in any overrides from the command line via --set and -f. If '--reset-values' is specified, this is ignored")
+	f.BoolVar(&opts.dependencyUpdate, "dependency-update", false, "run helm dependency update before rendering the chart")
+	f.IntVarP(&opts.context, "context", "C", 3, "number of context lines around every change")
+	f.BoolVar(&opts.noColor, "no-color", false, "disable colored output")
+	f.BoolVar(&opts.detailedExitcode, "detailed-exitcode", false, "return exit code 2 if there are changes")
+	bindPostRenderFlag(cmd, &clientUpgrade.PostRenderer)
+
+	return cmd
+}
+
+//Diff deployed release against a dry-run upgrade
+func RunDiff(
+	args []string,
+	cfg *action.Configuration,
+	clientUpgrade *action.Upgrade,
+	valueOpts *values.Options,
+	opts *diffOptions,
+	out io.Writer,
+) (bool, error) {
+
+	setLogger()
+	if err := initActionConfig(cfg); err != nil {
+		return false, err
+	}
+	if opts.noColor {
+		color.NoColor = true
+	}
+	return diffRelease(cfg, args[0], args[1], clientUpgrade, valueOpts, opts, out)
+}
+
+// diffRelease compares a deployed release with a dry-run upgrade to chart
+func diffRelease(
+	cfg *action.Configuration,
+	name string,
+	chart string,
+	clientUpgrade *action.Upgrade,
+	valueOpts *values.Options,
+	opts *diffOptions,
+	out io.Writer,
+) (bool, error) {
+	statusHelmChart, err := NewStatus(cfg, name)
+	if err != nil {
+		return false, err
+	}
+	current, err := statusHelmChart.InfoStatus()
+	if err != nil {
+		return false, err
+	}
+
+	clientUpgrade.DryRun = true
+	// Progress of a dependency update must not end up in the diff
+	proposed, _, err := RunUpgrade(clientUpgrade, cfg, name, chart, valueOpts, opts.dependencyUpdate, os.Stderr)
+	if err != nil {
+		return false, err
+	}
+	return diffReleases(out, current, proposed, opts.context)
+}
+
+// diffReleases prints a unified diff for every changed Kubernetes object
+func diffReleases(out io.Writer, current, proposed *release.Release, context int) (bool, error) {
+	oldObjects, err := releaseObjects(current)
+	if err != nil {
+		return false, err
+	}
+	newObjects, err := releaseObjects(proposed)
+	if err != nil {
+		return false, err
+	}
+
+	keys := make([]string, 0, len(oldObjects)+len(newObjects))
+	for k := range oldObjects {
+		keys = append(keys, k)
+	}
+	for k := range newObjects {
+		if _, ok := oldObjects[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	changed := false
+	for _, key := range keys {
+		oldContent, newContent := oldObjects[key], newObjects[key]
+		if strings.HasPrefix(key, "Secret/") {
+			if oldContent, newContent, err = maskSecrets(oldContent, newContent); err != nil {
+				return changed, errors.Wrapf(err, "could not mask %s", key)
+			}
+		}
+		if oldContent == newContent {
+			continue
+		}
+
+		changed = true
+		diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        difflib.SplitLines(oldContent),
+			B:        difflib.SplitLines(newContent),
+			FromFile: key + " (deployed)",
+			ToFile:   key + " (proposed)",
+			Context:  context,
+		})
+		if err != nil {
+			return changed, err
+		}
+		writeColoredDiff(out, diff)
+	}
+
+	if !changed {
+		fmt.Fprintln(out, "No changes detected")
+	}
+	return changed, nil
+}
+
+// releaseObjects indexes the release manifest by Kind/name
+func releaseObjects(rel *release.Release) (map[string]string, error) {
+	objects := map[string]string{}
+	if rel == nil {
+		return objects, nil
+	}
+	resources, err := releaseResources(rel, releaseutil.InstallOrder)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range resources {
+		objects[resourceName(m)] = strings.TrimSpace(m.Content)
+	}
+	return objects, nil
+}
+
+// maskSecrets hides Secret values, keeping track of which of them changed
+func maskSecrets(oldContent, newContent string) (string, string, error) {
+	oldSecret, err := parseObject(oldContent)
+	if err != nil {
+		return "", "", err
+	}
+	newSecret, err := parseObject(newContent)
+	if err != nil {
+		return "", "", err
+	}
+
+	for _, field := range []string{"data", "stringData"} {
+		oldData, _ := oldSecret[field].(map[string]interface{})
+		newData, _ := newSecret[field].(map[string]interface{})
+		for k, v := range newData {
+			if o, ok := oldData[k]; ok && reflect.DeepEqual(o, v) {
+				newData[k] = redacted
+			} else {
+				newData[k] = redacted + " changed"
+			}
+		}
+		for k := range oldData {
+			oldData[k] = redacted
+		}
+	}
+
+	oldMasked, err := formatObject(oldSecret)
+	if err != nil {
+		return "", "", err
+	}
+	newMasked, err := formatObject(newSecret)
+	if err != nil {
+		return "", "", err
+	}
+	return oldMasked, newMasked, nil
+}
+
+func parseObject(content string) (map[string]interface{}, error) {
+	if strings.TrimSpace(content) == "" {
+		return nil, nil
+	}
+	obj := map[string]interface{}{}
+	if err := yaml.Unmarshal([]byte(content), &obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+func formatObject(obj map[string]interface{}) (string, error) {
+	if obj == nil {
+		return "", nil
+	}
+	b, err := yaml.Marshal(obj)
+	return strings.TrimSpace(string(b)), err
+}
+
+func writeColoredDiff(out io.Writer, diff string) {
+	for _, line := range strings.SplitAfter(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "---"), strings.HasPrefix(line, "+++"):
+			color.New(color.Bold).Fprint(out, line)
+		case strings.HasPrefix(line, "@@"):
+			color.New(color.FgCyan).Fprint(out, line)
+		case strings.HasPrefix(line, "-"):
+			color.New(color.FgRed).Fprint(out, line)
+		case strings.HasPrefix(line, "+"):
+			color.New(color.FgGreen).Fprint(out, line)
+		default:
+			fmt.Fprint(out, line)
+		}
+	}
+}
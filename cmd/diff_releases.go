@@ -0,0 +1,135 @@
+/*
+Copyright © 2020 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"helm.sh/helm/v3/cmd/helm/require"
+	"helm.sh/helm/v3/pkg/storage/driver"
+)
+
+const diffReleasesDesc = `
+Preview the changes 'lincos apply' would make to the releases of a lincos.yaml
+file.
+
+Every release is compared with a dry-run upgrade like 'lincos helm diff'
+does. Releases which are not installed yet are reported as such. Use
+'--environment' and '--selector' like with 'lincos apply'.
+`
+
+type diffReleasesOptions struct {
+	lincosFileOptions
+	diffOptions
+}
+
+func newDiffReleasesCmd(out io.Writer) *cobra.Command {
+	o := &diffReleasesOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "diff",
+		Short: "Preview the changes to the releases of a lincos.yaml file",
+		Long:  diffReleasesDesc,
+		Args:  require.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Flags are parsed by now, failing releases are no usage errors
+			cmd.SilenceUsage = true
+			changed, err := RunDiffReleases(o, out)
+			if err != nil {
+				return err
+			}
+			if changed && o.detailedExitcode {
+				cmd.SilenceErrors = true
+				return pluginError{errors.New("releases have changes"), 2}
+			}
+			return nil
+		},
+	}
+
+	f := cmd.Flags()
+	addLincosFileFlags(f, &o.lincosFileOptions)
+	f.IntVarP(&o.context, "context", "C", 3, "number of context lines around every change")
+	f.BoolVar(&o.noColor, "no-color", false, "disable colored output")
+	f.BoolVar(&o.detailedExitcode, "detailed-exitcode", false, "return exit code 2 if there are changes")
+	flags := cmd.PersistentFlags()
+	settings.AddFlags(flags)
+
+	return cmd
+}
+
+//Diff the releases of a lincos.yaml against dry-run upgrades
+func RunDiffReleases(o *diffReleasesOptions, out io.Writer) (bool, error) {
+
+	setLogger()
+	if o.noColor {
+		color.NoColor = true
+	}
+	releases, cleanup, err := o.releases()
+	if err != nil {
+		return false, err
+	}
+	defer cleanup()
+
+	changed, failed := false, 0
+	for _, r := range releases {
+		c, err := diffReleaseSpec(r, o.diffOptions, out)
+		if err != nil {
+			log.WithTime(time.Now()).WithFields(log.Fields{
+				"release":     r.Name,
+				"chart":       r.Chart,
+				"KubeContext": r.KubeContext,
+				"Error":       err,
+			}).Error("Release could not be diffed.")
+			failed++
+		}
+		changed = changed || c
+	}
+	if failed > 0 {
+		return changed, errors.Errorf("%d of %d releases failed", failed, len(releases))
+	}
+	return changed, nil
+}
+
+// diffReleaseSpec prints the changes of one release of a lincos.yaml
+func diffReleaseSpec(r *releaseSpec, opts diffOptions, out io.Writer) (bool, error) {
+	cfg, namespace, err := r.actionConfig()
+	if err != nil {
+		return false, err
+	}
+	color.New(color.Bold).Fprintf(out, "Release \"%s\" in namespace \"%s\":\n", r.Name, namespace)
+
+	status, err := NewStatus(cfg, r.Name)
+	if err != nil {
+		return false, err
+	}
+	if _, err := status.InfoStatus(); errors.Cause(err) == driver.ErrReleaseNotFound {
+		fmt.Fprintln(out, "Release is not installed, it would be installed")
+		return true, nil
+	}
+
+	_, clientUpgrade := r.clients(cfg)
+	clientUpgrade.Namespace = namespace
+	opts.dependencyUpdate = r.DependencyUpdate
+	return diffRelease(cfg, r.Name, r.Chart, clientUpgrade, r.valueOptions(), &opts, out)
+}
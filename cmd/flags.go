@@ -76,6 +76,7 @@ func addValueOptionsFlags(f *pflag.FlagSet, v *values.Options) {
 func addChartPathOptionsFlags(f *pflag.FlagSet, c *action.ChartPathOptions) {
 	f.StringVar(&c.Version, "version", "", "specify the exact chart version to use. If this is not specified, the latest version is used")
 	f.BoolVar(&c.Verify, "verify", false, "verify the package before using it")
+	f.StringVar(&c.Keyring, "keyring", defaultKeyring(), "location of public keys used for verification")
 	f.StringVar(&c.RepoURL, "repo", "", "chart repository url where to locate the requested chart")
 	f.StringVar(&c.Username, "username", "", "chart repository username where to locate the requested chart")
 	f.StringVar(&c.Password, "password", "", "chart repository password where to locate the requested chart")
@@ -83,12 +84,14 @@ func addChartPathOptionsFlags(f *pflag.FlagSet, c *action.ChartPathOptions) {
 	f.StringVar(&c.KeyFile, "key-file", "", "identify HTTPS client using this SSL key file")
 	f.BoolVar(&c.InsecureSkipTLSverify, "insecure-skip-tls-verify", false, "skip tls certificate checks for the chart download")
 	f.StringVar(&c.CaFile, "ca-file", "", "verify certificates of HTTPS-enabled servers using this CA bundle")
+	f.BoolVar(&registryPlainHTTP, "plain-http", false, "use plain HTTP to pull oci:// charts from the registry")
 }
 
 func addChartPathOptionsFlagsInstall(client *action.Install, clientUpgrade *action.Upgrade) {
 	client.Version = clientUpgrade.Version
 	client.RepoURL = clientUpgrade.RepoURL
 	client.Verify = clientUpgrade.Verify
+	client.Keyring = clientUpgrade.Keyring
 	client.Username = clientUpgrade.Username
 	client.Username = clientUpgrade.Username
 	client.Password = clientUpgrade.Password
@@ -174,6 +177,21 @@ func (p postRenderer) Set(s string) error {
 	return nil
 }
 
+// bindVersionCompletion completes --version with the versions of the chart
+// given as the argument at position chartArg
+func bindVersionCompletion(cmd *cobra.Command, chartArg int) {
+	err := cmd.RegisterFlagCompletionFunc("version", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) <= chartArg {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return compVersionFlag(args[chartArg], toComplete)
+	})
+
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
 func compVersionFlag(chartRef string, toComplete string) ([]string, cobra.ShellCompDirective) {
 	chartInfo := strings.Split(chartRef, "/")
 	if len(chartInfo) != 2 {
@@ -0,0 +1,78 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"helm.sh/helm/v3/cmd/helm/require"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/cli/output"
+	"helm.sh/helm/v3/pkg/release"
+)
+
+const getHooksHelp = `
+This command downloads hooks for a given release.
+
+Hooks are formatted in YAML and separated by the YAML '---\n' separator.
+`
+
+type hooksWriter struct {
+	hooks []*release.Hook
+}
+
+func newGetHooksCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
+	var outfmt output.Format
+	var revision int
+	cmd := &cobra.Command{
+		Use:               "hooks [release name]",
+		Short:             "Download all hooks for a named release",
+		Long:              getHooksHelp,
+		Args:              require.ExactArgs(1),
+		ValidArgsFunction: compReleaseArg(cfg),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			rel, err := RunStatus(args[0], revision, cfg)
+			if err != nil {
+				return err
+			}
+			return outfmt.Write(out, &hooksWriter{rel.Hooks})
+		},
+	}
+
+	cmd.Flags().IntVar(&revision, "revision", 0, "get the named release with revision")
+	bindOutputFlag(cmd, &outfmt)
+
+	return cmd
+}
+
+func (h hooksWriter) WriteTable(out io.Writer) error {
+	for _, hook := range h.hooks {
+		fmt.Fprintf(out, "---\n# Source: %s\n%s\n", hook.Path, hook.Manifest)
+	}
+	return nil
+}
+
+func (h hooksWriter) WriteJSON(out io.Writer) error {
+	return output.EncodeJSON(out, h.hooks)
+}
+
+func (h hooksWriter) WriteYAML(out io.Writer) error {
+	return output.EncodeYAML(out, h.hooks)
+}
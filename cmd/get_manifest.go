@@ -0,0 +1,108 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"helm.sh/helm/v3/cmd/helm/require"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/cli/output"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/releaseutil"
+)
+
+var getManifestHelp = `
+This command fetches the generated manifest for a given release.
+
+A manifest is a YAML-encoded representation of the Kubernetes resources that
+were generated from this release's chart(s). If a chart is dependent on other
+charts, those resources will also be included in the manifest.
+
+The json and yaml formats print the manifest as a list of objects.
+`
+
+type manifestWriter struct {
+	release *release.Release
+}
+
+func newGetManifestCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
+	var outfmt output.Format
+	var revision int
+	cmd := &cobra.Command{
+		Use:               "manifest [release name]",
+		Short:             "Download the manifest for a named release",
+		Long:              getManifestHelp,
+		Args:              require.ExactArgs(1),
+		ValidArgsFunction: compReleaseArg(cfg),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			rel, err := RunStatus(args[0], revision, cfg)
+			if err != nil {
+				return err
+			}
+			return outfmt.Write(out, &manifestWriter{rel})
+		},
+	}
+
+	cmd.Flags().IntVar(&revision, "revision", 0, "get the named release with revision")
+	bindOutputFlag(cmd, &outfmt)
+
+	return cmd
+}
+
+// objects parses every document of the manifest
+func (m manifestWriter) objects() ([]map[string]interface{}, error) {
+	resources, err := releaseResources(m.release, releaseutil.InstallOrder)
+	if err != nil {
+		return nil, err
+	}
+	objects := make([]map[string]interface{}, 0, len(resources))
+	for _, r := range resources {
+		obj, err := parseObject(r.Content)
+		if err != nil {
+			return nil, err
+		}
+		if obj != nil {
+			objects = append(objects, obj)
+		}
+	}
+	return objects, nil
+}
+
+func (m manifestWriter) WriteTable(out io.Writer) error {
+	fmt.Fprintln(out, m.release.Manifest)
+	return nil
+}
+
+func (m manifestWriter) WriteJSON(out io.Writer) error {
+	objects, err := m.objects()
+	if err != nil {
+		return err
+	}
+	return output.EncodeJSON(out, objects)
+}
+
+func (m manifestWriter) WriteYAML(out io.Writer) error {
+	objects, err := m.objects()
+	if err != nil {
+		return err
+	}
+	return output.EncodeYAML(out, objects)
+}
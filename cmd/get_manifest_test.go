@@ -0,0 +1,63 @@
+/*
+Copyright © 2020 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"testing"
+
+	"helm.sh/helm/v3/pkg/release"
+)
+
+func TestManifestObjectsInstallOrder(t *testing.T) {
+	manifest := `---
+# Source: app/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+---
+# Source: app/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: app
+---
+# Source: app/templates/config.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+`
+	objects, err := manifestWriter{release: &release.Release{Manifest: manifest}}.objects()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var kinds []string
+	for _, obj := range objects {
+		kinds = append(kinds, obj["kind"].(string))
+	}
+	expected := []string{"ConfigMap", "Service", "Deployment"}
+	if len(kinds) != len(expected) {
+		t.Fatalf("expected kinds %v, got %v", expected, kinds)
+	}
+	for i := range expected {
+		if kinds[i] != expected[i] {
+			t.Fatalf("expected kinds %v, got %v", expected, kinds)
+		}
+	}
+}
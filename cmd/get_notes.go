@@ -0,0 +1,75 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"helm.sh/helm/v3/cmd/helm/require"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/cli/output"
+)
+
+var getNotesHelp = `
+This command shows notes provided by the chart of a named release.
+`
+
+type notesWriter struct {
+	Notes string `json:"notes"`
+}
+
+func newGetNotesCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
+	var outfmt output.Format
+	var revision int
+	cmd := &cobra.Command{
+		Use:               "notes [release name]",
+		Short:             "Download the notes for a named release",
+		Long:              getNotesHelp,
+		Args:              require.ExactArgs(1),
+		ValidArgsFunction: compReleaseArg(cfg),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			rel, err := RunStatus(args[0], revision, cfg)
+			if err != nil {
+				return err
+			}
+			return outfmt.Write(out, &notesWriter{rel.Info.Notes})
+		},
+	}
+
+	cmd.Flags().IntVar(&revision, "revision", 0, "get the named release with revision")
+	bindOutputFlag(cmd, &outfmt)
+
+	return cmd
+}
+
+func (n notesWriter) WriteTable(out io.Writer) error {
+	if len(n.Notes) > 0 {
+		fmt.Fprintf(out, "NOTES:\n%s\n", n.Notes)
+	}
+	return nil
+}
+
+func (n notesWriter) WriteJSON(out io.Writer) error {
+	return output.EncodeJSON(out, n)
+}
+
+func (n notesWriter) WriteYAML(out io.Writer) error {
+	return output.EncodeYAML(out, n)
+}
@@ -0,0 +1,100 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"helm.sh/helm/v3/cmd/helm/require"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/cli/output"
+)
+
+var getValuesHelp = `
+This command downloads a values file for a given release.
+`
+
+type valuesWriter struct {
+	vals      map[string]interface{}
+	allValues bool
+}
+
+func newGetValuesCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
+	var outfmt output.Format
+	var revision int
+	var allValues bool
+	cmd := &cobra.Command{
+		Use:               "values [release name]",
+		Short:             "Download the values file for a named release",
+		Long:              getValuesHelp,
+		Args:              require.ExactArgs(1),
+		ValidArgsFunction: compReleaseArg(cfg),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			vals, err := RunGetValues(args[0], revision, allValues, cfg)
+			if err != nil {
+				return err
+			}
+			return outfmt.Write(out, &valuesWriter{vals, allValues})
+		},
+	}
+
+	f := cmd.Flags()
+	f.IntVar(&revision, "revision", 0, "get the named release with revision")
+	f.BoolVarP(&allValues, "all", "a", false, "dump all (computed) values")
+	bindOutputFlag(cmd, &outfmt)
+
+	return cmd
+}
+
+//Get release values
+func RunGetValues(name string, revision int, allValues bool, cfg *action.Configuration) (map[string]interface{}, error) {
+	rel, err := RunStatus(name, revision, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// If the user wants all values, compute the values and return.
+	if allValues {
+		vals, err := chartutil.CoalesceValues(rel.Chart, rel.Config)
+		if err != nil {
+			return nil, err
+		}
+		return vals.AsMap(), nil
+	}
+	return rel.Config, nil
+}
+
+func (v valuesWriter) WriteTable(out io.Writer) error {
+	if v.allValues {
+		fmt.Fprintln(out, "COMPUTED VALUES:")
+	} else {
+		fmt.Fprintln(out, "USER-SUPPLIED VALUES:")
+	}
+	return output.EncodeYAML(out, v.vals)
+}
+
+func (v valuesWriter) WriteJSON(out io.Writer) error {
+	return output.EncodeJSON(out, v.vals)
+}
+
+func (v valuesWriter) WriteYAML(out io.Writer) error {
+	return output.EncodeYAML(out, v.vals)
+}
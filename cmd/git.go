@@ -0,0 +1,222 @@
+/*
+Copyright © 2020 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"helm.sh/helm/v3/pkg/helmpath"
+)
+
+// gitScheme is the prefix of charts stored in git repositories, e.g.
+// git+ssh://git@example.com/org/charts.git//charts/api?ref=v1.4.0
+const gitScheme = "git+"
+
+var commitSHA = regexp.MustCompile("^[0-9a-f]{40}$")
+
+type gitReference struct {
+	Remote string
+	Path   string
+	Ref    string
+}
+
+// parseGitReference splits a git+ reference into remote, chart path and ref
+func parseGitReference(ref string) (*gitReference, error) {
+	s := strings.TrimPrefix(ref, gitScheme)
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid git reference %s", ref)
+	}
+	if u.Scheme == "" {
+		return nil, errors.Errorf("invalid git reference %s, expected git+<scheme>://<remote>//<path>?ref=<ref>", ref)
+	}
+
+	r := &gitReference{Ref: u.Query().Get("ref")}
+	u.RawQuery = ""
+
+	// The chart path inside the repository follows a double slash
+	remote := u.String()
+	schemeEnd := strings.Index(remote, "://") + len("://")
+	if i := strings.Index(remote[schemeEnd:], "//"); i >= 0 {
+		r.Remote = remote[:schemeEnd+i]
+		r.Path = strings.Trim(remote[schemeEnd+i+2:], "/")
+	} else {
+		r.Remote = remote
+	}
+	return r, nil
+}
+
+// pinGitReference replaces the ref of a git+ reference with a commit
+func pinGitReference(ref, commit string) (string, error) {
+	u, err := url.Parse(strings.TrimPrefix(ref, gitScheme))
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid git reference %s", ref)
+	}
+	q := u.Query()
+	q.Set("ref", commit)
+	u.RawQuery = q.Encode()
+	return gitScheme + u.String(), nil
+}
+
+// gitCache is the root of the cloned repositories and checkouts
+func gitCache(elem ...string) string {
+	return helmpath.CachePath(append([]string{"git"}, elem...)...)
+}
+
+// checkoutGitChart fetches the ref into a mirror of the remote and checks the
+// resolved commit out into a directory addressed by its SHA. It returns the
+// chart path and the commit SHA.
+func checkoutGitChart(ref string) (string, string, error) {
+	r, err := parseGitReference(ref)
+	if err != nil {
+		return "", "", err
+	}
+
+	mirror := gitCache("mirrors", fmt.Sprintf("%x", sha256.Sum256([]byte(r.Remote))))
+	defer lockGitMirror(mirror)()
+	sha, err := resolveGitRef(mirror, r)
+	if err != nil {
+		return "", "", err
+	}
+	debug("We resolved \"%s\" to commit %s", ref, sha)
+
+	checkout := gitCache("checkouts", sha)
+	if _, err := os.Stat(checkout); os.IsNotExist(err) {
+		if err := checkoutGitCommit(mirror, sha, checkout); err != nil {
+			return "", "", err
+		}
+	}
+
+	chartPath := filepath.Join(checkout, filepath.FromSlash(r.Path))
+	if _, err := os.Stat(chartPath); err != nil {
+		return "", "", errors.Errorf("path %q not found in %s at %s", r.Path, r.Remote, sha)
+	}
+	return chartPath, sha, nil
+}
+
+// gitMirrorLocks serializes the use of a mirror. Releases deployed at the same
+// time must not see each other's FETCH_HEAD or share the index of a checkout.
+var gitMirrorLocks = struct {
+	sync.Mutex
+	mirrors map[string]*sync.Mutex
+}{mirrors: map[string]*sync.Mutex{}}
+
+func lockGitMirror(mirror string) func() {
+	gitMirrorLocks.Lock()
+	l, ok := gitMirrorLocks.mirrors[mirror]
+	if !ok {
+		l = &sync.Mutex{}
+		gitMirrorLocks.mirrors[mirror] = l
+	}
+	gitMirrorLocks.Unlock()
+
+	l.Lock()
+	return l.Unlock
+}
+
+// resolveGitRef creates the mirror of the remote when needed and fetches the
+// ref into it, it returns the commit SHA of the ref
+func resolveGitRef(mirror string, r *gitReference) (string, error) {
+	if _, err := os.Stat(mirror); os.IsNotExist(err) {
+		if _, err := runGit("", "init", "--bare", "--quiet", mirror); err != nil {
+			return "", err
+		}
+	}
+	return fetchGitRef(mirror, r)
+}
+
+// checkoutGitCommit checks a commit out into a temporary directory first so
+// that an interrupted checkout never ends up in the cache. The same commit may
+// be checked out from another remote at the same time, whichever checkout is
+// renamed first wins.
+func checkoutGitCommit(mirror, sha, checkout string) error {
+	if err := os.MkdirAll(filepath.Dir(checkout), 0755); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempDir(filepath.Dir(checkout), sha+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	if _, err := runGit(mirror, "--work-tree", tmp, "checkout", "--force", sha, "--", "."); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, checkout); err != nil {
+		if _, statErr := os.Stat(checkout); statErr != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fetchGitRef fetches a branch, tag or commit and returns its commit SHA
+func fetchGitRef(mirror string, r *gitReference) (string, error) {
+	ref := r.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+
+	if _, err := runGit(mirror, "fetch", "--quiet", "--force", r.Remote, ref); err != nil {
+		// Not every server allows to fetch a commit directly
+		if !commitSHA.MatchString(ref) {
+			return "", err
+		}
+		if _, err := runGit(mirror, "fetch", "--quiet", "--force", r.Remote, "+refs/heads/*:refs/heads/*", "+refs/tags/*:refs/tags/*"); err != nil {
+			return "", err
+		}
+		return runGit(mirror, "rev-parse", "--verify", ref+"^{commit}")
+	}
+	return runGit(mirror, "rev-parse", "--verify", "FETCH_HEAD^{commit}")
+}
+
+// runGit runs git against the given repository and returns its trimmed output
+func runGit(gitDir string, args ...string) (string, error) {
+	if gitDir != "" {
+		args = append([]string{"--git-dir", gitDir}, args...)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// Never block on a credentials prompt, SSH remotes use the ssh agent or keys
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if err := cmd.Run(); err != nil {
+		return "", errors.Errorf("git %s: %s", strings.Join(args, " "), strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// gitDescription records the deployed commit in the release description
+func gitDescription(description, defaultDescription, sha string) string {
+	if description == "" {
+		description = defaultDescription
+	}
+	return fmt.Sprintf("%s (git commit %s)", description, sha)
+}
@@ -0,0 +1,123 @@
+/*
+Copyright © 2020 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// gitTestRepo creates a repository with the chart version of every tag
+func gitTestRepo(t *testing.T, dir string, tags ...string) map[string]string {
+	t.Helper()
+	git := func(args ...string) string {
+		out, err := runGit(filepath.Join(dir, ".git"), append([]string{"--work-tree", dir}, args...)...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+	if err := exec.Command("git", "init", "--quiet", dir).Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	shas := map[string]string{}
+	for _, tag := range tags {
+		chart := "apiVersion: v2\nname: api\nversion: " + tag + "\n"
+		if err := os.MkdirAll(filepath.Join(dir, "charts", "api"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, "charts", "api", "Chart.yaml"), []byte(chart), 0644); err != nil {
+			t.Fatal(err)
+		}
+		git("add", "-A")
+		git("-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "-m", tag)
+		git("tag", tag)
+		shas[tag] = git("rev-parse", "HEAD")
+	}
+	return shas
+}
+
+func TestCheckoutGitChartConcurrently(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir, err := ioutil.TempDir("", "lincos-git")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer os.Setenv("HELM_CACHE_HOME", os.Getenv("HELM_CACHE_HOME"))
+	os.Setenv("HELM_CACHE_HOME", filepath.Join(dir, "cache"))
+
+	repo := filepath.Join(dir, "repo")
+	shas := gitTestRepo(t, repo, "1.0.0", "2.0.0")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		for tag, expected := range shas {
+			wg.Add(1)
+			go func(tag, expected string) {
+				defer wg.Done()
+				chartPath, sha, err := checkoutGitChart("git+file://" + repo + "//charts/api?ref=" + tag)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if sha != expected {
+					t.Errorf("expected %s to resolve to %s, got %s", tag, expected, sha)
+				}
+				data, err := ioutil.ReadFile(filepath.Join(chartPath, "Chart.yaml"))
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if want := "apiVersion: v2\nname: api\nversion: " + tag + "\n"; string(data) != want {
+					t.Errorf("expected the chart of %s, got %q", tag, data)
+				}
+			}(tag, expected)
+		}
+	}
+	wg.Wait()
+}
+
+func TestPinGitReference(t *testing.T) {
+	tests := map[string]string{
+		"git+ssh://git@example.com/org/charts.git//charts/api?ref=v1.4.0": "git+ssh://git@example.com/org/charts.git//charts/api?ref=0123456789abcdef0123456789abcdef01234567",
+		"git+https://example.com/org/charts.git//api":                     "git+https://example.com/org/charts.git//api?ref=0123456789abcdef0123456789abcdef01234567",
+	}
+	for ref, expected := range tests {
+		pinned, err := pinGitReference(ref, "0123456789abcdef0123456789abcdef01234567")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pinned != expected {
+			t.Errorf("expected %s to be pinned to %s, got %s", ref, expected, pinned)
+		}
+		r, err := parseGitReference(pinned)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.Ref != "0123456789abcdef0123456789abcdef01234567" {
+			t.Errorf("expected %s to resolve the commit, got %s", pinned, r.Ref)
+		}
+	}
+}
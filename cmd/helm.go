@@ -22,6 +22,8 @@ import (
 	"helm.sh/helm/v3/pkg/action"
 	"helm.sh/helm/v3/pkg/cli"
 	"io"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"os"
 	"time"
 )
 
@@ -45,11 +47,64 @@ func newHelmInitCmd(out io.Writer) *cobra.Command {
 
 	actionConfig := new(action.Configuration)
 
-	cmd.AddCommand(newDeployCmd(actionConfig, out))
+	cmd.AddCommand(
+		newDeployCmd(actionConfig, out),
+		newRollbackCmd(actionConfig, out),
+		newUninstallCmd(actionConfig, os.Stdin, out),
+		newHistoryCmd(actionConfig, out),
+		newStatusCmd(actionConfig, out),
+		newListCmd(actionConfig, out),
+		newTemplateCmd(actionConfig, out),
+		newDiffCmd(actionConfig, out),
+		newLintCmd(out),
+		newReleaseTestCmd(actionConfig, out),
+		newGetCmd(actionConfig, out),
+	)
 	debug("RunDeploy: test2")
 	return cmd
 }
 
+// Initialize action configuration for the current kube context and namespace
+func initActionConfig(cfg *action.Configuration) error {
+	return cfg.Init(settings.RESTClientGetter(), settings.Namespace(), os.Getenv("HELM_DRIVER"), log.Printf)
+}
+
+// kubeConfig returns the kube config of the settings for a kube context and
+// namespace, with the namespace it resolves to. An empty kube context keeps
+// the one of the settings, an empty namespace falls back to --namespace and
+// then to the namespace of the kube context. The kube client places objects
+// without a namespace in the namespace of the kube config, so it must be the
+// namespace the release is stored in.
+func kubeConfig(kubeContext, namespace string) (genericclioptions.RESTClientGetter, string) {
+	if kubeContext == "" {
+		kubeContext = settings.KubeContext
+	}
+	if namespace == "" {
+		if flags, ok := settings.RESTClientGetter().(*genericclioptions.ConfigFlags); ok && flags.Namespace != nil {
+			namespace = *flags.Namespace
+		}
+	}
+	getter := &genericclioptions.ConfigFlags{
+		Namespace:   &namespace,
+		Context:     &kubeContext,
+		BearerToken: &settings.KubeToken,
+		APIServer:   &settings.KubeAPIServer,
+		KubeConfig:  &settings.KubeConfig,
+	}
+	if namespace == "" {
+		namespace = kubeNamespace(getter)
+	}
+	return getter, namespace
+}
+
+// kubeNamespace returns the namespace of a kube config, like settings.Namespace()
+func kubeNamespace(getter genericclioptions.RESTClientGetter) string {
+	if ns, _, err := getter.ToRawKubeConfigLoader().Namespace(); err == nil {
+		return ns
+	}
+	return "default"
+}
+
 // Setting up logger
 func setLogger() {
 	log.SetLevel(log.InfoLevel)
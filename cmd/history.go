@@ -0,0 +1,243 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/gosuri/uitable"
+	"github.com/spf13/cobra"
+
+	"helm.sh/helm/v3/cmd/helm/require"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/cli/output"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/releaseutil"
+	helmtime "helm.sh/helm/v3/pkg/time"
+)
+
+var historyHelp = `
+History prints historical revisions for a given release.
+
+A default maximum of 256 revisions will be returned. Setting '--max'
+configures the maximum length of the revision list returned.
+
+With '--values-diff' every revision also lists the user-supplied values
+that changed compared to the previous revision.
+`
+
+func newHistoryCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
+	client := action.NewHistory(cfg)
+	var outfmt output.Format
+	var valuesDiff bool
+	cmd := &cobra.Command{
+		Use:               "history [release name]",
+		Long:              historyHelp,
+		Short:             "Fetch release history",
+		Aliases:           []string{"hist"},
+		Args:              require.ExactArgs(1),
+		ValidArgsFunction: compReleaseArg(cfg),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			history, err := RunHistory(args[0], cfg, client, valuesDiff)
+			if err != nil {
+				return err
+			}
+			return outfmt.Write(out, history)
+		},
+	}
+
+	f := cmd.Flags()
+	f.IntVar(&client.Max, "max", 256, "maximum number of revision to include in history")
+	f.BoolVar(&valuesDiff, "values-diff", false, "show which user-supplied values changed between adjacent revisions")
+	bindOutputFlag(cmd, &outfmt)
+
+	return cmd
+}
+
+type valueChange struct {
+	Key string      `json:"key"`
+	Old interface{} `json:"old,omitempty"`
+	New interface{} `json:"new,omitempty"`
+}
+
+type releaseInfo struct {
+	Revision      int           `json:"revision"`
+	Updated       helmtime.Time `json:"updated"`
+	Status        string        `json:"status"`
+	Chart         string        `json:"chart"`
+	AppVersion    string        `json:"app_version"`
+	Description   string        `json:"description"`
+	ValuesChanged []valueChange `json:"values_changed,omitempty"`
+}
+
+type releaseHistory []releaseInfo
+
+func (r releaseHistory) WriteJSON(out io.Writer) error {
+	return output.EncodeJSON(out, r)
+}
+
+func (r releaseHistory) WriteYAML(out io.Writer) error {
+	return output.EncodeYAML(out, r)
+}
+
+func (r releaseHistory) WriteTable(out io.Writer) error {
+	tbl := uitable.New()
+	tbl.AddRow("REVISION", "UPDATED", "STATUS", "CHART", "APP VERSION", "DESCRIPTION")
+	for _, item := range r {
+		tbl.AddRow(item.Revision, item.Updated.Format(time.ANSIC), item.Status, item.Chart, item.AppVersion, item.Description)
+	}
+	if err := output.EncodeTable(out, tbl); err != nil {
+		return err
+	}
+
+	for _, item := range r {
+		if len(item.ValuesChanged) == 0 {
+			continue
+		}
+		fmt.Fprintf(out, "\nREVISION %d VALUES CHANGED:\n", item.Revision)
+		for _, c := range item.ValuesChanged {
+			fmt.Fprintf(out, "  %s: %v -> %v\n", c.Key, formatValue(c.Old), formatValue(c.New))
+		}
+	}
+	return nil
+}
+
+//Get release history
+func RunHistory(name string, cfg *action.Configuration, client *action.History, valuesDiff bool) (releaseHistory, error) {
+
+	setLogger()
+	if err := initActionConfig(cfg); err != nil {
+		return nil, err
+	}
+	debug("We fetch history of release \"%s\"", name)
+
+	hist, err := client.Run(name)
+	if err != nil {
+		return nil, err
+	}
+
+	releaseutil.Reverse(hist, releaseutil.SortByRevision)
+
+	var history releaseHistory
+	for i := min(len(hist), client.Max) - 1; i >= 0; i-- {
+		rInfo := getReleaseInfo(hist[i])
+		// The oldest revision shown is still compared with its predecessor
+		if valuesDiff && i+1 < len(hist) {
+			rInfo.ValuesChanged = diffValues(hist[i+1].Config, hist[i].Config)
+		}
+		history = append(history, rInfo)
+	}
+
+	if history == nil {
+		return releaseHistory{}, nil
+	}
+	return history, nil
+}
+
+func getReleaseInfo(r *release.Release) releaseInfo {
+	rInfo := releaseInfo{
+		Revision:    r.Version,
+		Status:      r.Info.Status.String(),
+		Chart:       formatChartname(r.Chart),
+		AppVersion:  formatAppVersion(r.Chart),
+		Description: r.Info.Description,
+	}
+	if !r.Info.LastDeployed.IsZero() {
+		rInfo.Updated = r.Info.LastDeployed
+	}
+	return rInfo
+}
+
+// diffValues returns the keys that differ between two sets of values
+func diffValues(prev, cur map[string]interface{}) []valueChange {
+	oldValues := flattenValues("", prev)
+	newValues := flattenValues("", cur)
+
+	var changes []valueChange
+	for k, o := range oldValues {
+		n, ok := newValues[k]
+		if !ok {
+			changes = append(changes, valueChange{Key: k, Old: o})
+		} else if !reflect.DeepEqual(o, n) {
+			changes = append(changes, valueChange{Key: k, Old: o, New: n})
+		}
+	}
+	for k, n := range newValues {
+		if _, ok := oldValues[k]; !ok {
+			changes = append(changes, valueChange{Key: k, New: n})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+	return changes
+}
+
+// flattenValues converts nested values to a map of dotted keys
+func flattenValues(prefix string, values map[string]interface{}) map[string]interface{} {
+	result := map[string]interface{}{}
+	for k, v := range values {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		if nested, ok := v.(map[string]interface{}); ok && len(nested) > 0 {
+			for nk, nv := range flattenValues(key, nested) {
+				result[nk] = nv
+			}
+			continue
+		}
+		result[key] = v
+	}
+	return result
+}
+
+func formatValue(v interface{}) string {
+	if v == nil {
+		return "<none>"
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+func formatChartname(c *chart.Chart) string {
+	if c == nil || c.Metadata == nil {
+		// This is an edge case that has happened in prod, though we don't
+		// know how: https://github.com/helm/helm/issues/1347
+		return "MISSING"
+	}
+	return fmt.Sprintf("%s-%s", c.Name(), c.Metadata.Version)
+}
+
+func formatAppVersion(c *chart.Chart) string {
+	if c == nil || c.Metadata == nil {
+		// This is an edge case that has happened in prod, though we don't
+		// know how: https://github.com/helm/helm/issues/1347
+		return "MISSING"
+	}
+	return c.AppVersion()
+}
+
+func min(x, y int) int {
+	if x < y {
+		return x
+	}
+	return y
+}
@@ -21,9 +21,7 @@ import (
 	"github.com/pkg/errors"
 	"helm.sh/helm/v3/pkg/action"
 	"helm.sh/helm/v3/pkg/chart"
-	"helm.sh/helm/v3/pkg/chart/loader"
 	"helm.sh/helm/v3/pkg/cli/values"
-	"helm.sh/helm/v3/pkg/downloader"
 	"helm.sh/helm/v3/pkg/getter"
 	"helm.sh/helm/v3/pkg/release"
 	"io"
@@ -38,15 +36,20 @@ func RunInstall(
 	chart string,
 	valueOpts *values.Options,
 	out io.Writer,
-) (*release.Release, error) {
+) (*release.Release, *provenanceReport, error) {
 	debug("We use chart name for deployment: %s", releaseName)
 	client.ReleaseName = releaseName
-	client.Namespace = settings.Namespace()
+	if client.Namespace == "" {
+		client.Namespace = settings.Namespace()
+	}
 	debug("RunInstall Namespace:", client.Namespace)
 
-	cp, err := client.ChartPathOptions.LocateChart(chart, settings)
+	cp, commit, report, err := locateReleaseChart(&client.ChartPathOptions, chart, client.Namespace)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	if commit != "" && !client.DryRun {
+		client.Description = gitDescription(client.Description, "Install complete", commit)
 	}
 
 	debug("CHART PATH:", cp)
@@ -54,53 +57,25 @@ func RunInstall(
 	p := getter.All(settings)
 	vals, err := valueOpts.MergeValues(p)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// Check chart dependencies to make sure all are present in /charts
-	chartRequested, err := loader.Load(cp)
+	chartRequested, err := loadChart(cp, &client.ChartPathOptions, client.DependencyUpdate, out)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	if err := checkIfInstallable(chartRequested); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	if chartRequested.Metadata.Deprecated {
 		warning("This chart is deprecated")
 	}
 
-	if req := chartRequested.Metadata.Dependencies; req != nil {
-		// If CheckDependencies returns an error, we have unfulfilled dependencies.
-		// As of Helm 2.4.0, this is treated as a stopping condition:
-		// https://github.com/helm/helm/issues/2209
-		if err := action.CheckDependencies(chartRequested, req); err != nil {
-			if client.DependencyUpdate {
-				man := &downloader.Manager{
-					Out:              out,
-					ChartPath:        cp,
-					Keyring:          client.ChartPathOptions.Keyring,
-					SkipUpdate:       false,
-					Getters:          p,
-					RepositoryConfig: settings.RepositoryConfig,
-					RepositoryCache:  settings.RepositoryCache,
-					Debug:            settings.Debug,
-				}
-				if err := man.Update(); err != nil {
-					return nil, err
-				}
-				// Reload the chart with the updated Chart.lock file.
-				if chartRequested, err = loader.Load(cp); err != nil {
-					return nil, errors.Wrap(err, "failed reloading chart after repo update")
-				}
-			} else {
-				return nil, err
-			}
-		}
-	}
-
-	return client.Run(chartRequested, vals)
+	rel, err := client.Run(chartRequested, vals)
+	return rel, report, err
 }
 
 // checkIfInstallable validates if a chart can be installed
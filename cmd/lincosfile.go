@@ -0,0 +1,469 @@
+/*
+Copyright © 2020 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/Masterminds/sprig/v3"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/pflag"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/yaml"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/cli/values"
+)
+
+const defaultLincosFile = "lincos.yaml"
+
+// lincosFile declares the desired releases:
+//
+//	releases:
+//	- name: api
+//	  chart: stable/nginx
+//	  version: ^1.2
+//	  namespace: web
+//	  labels:
+//	    tier: backend
+//	  values:
+//	  - values/api.yaml
+//	  set:
+//	  - image.tag=1.2.3
+//	  wait: true
+//	  timeout: 10m
+//	  needs:
+//	  - db
+//	environments:
+//	  staging:
+//	    kubeContext: staging
+//	    releases:
+//	      api:
+//	        version: 1.3.0
+//	        values:
+//	        - values/api-staging.yaml
+//
+// Releases deployed by lincos are marked as managed by the project of the
+// file, the name of its directory unless 'project' is given. Pruning needs an
+// explicit project, directory names are neither unique nor stable.
+type lincosFile struct {
+	Project      string                      `json:"project,omitempty"`
+	Releases     []*releaseSpec              `json:"releases"`
+	Environments map[string]*environmentSpec `json:"environments,omitempty"`
+
+	// defaultProject is set when the project is the name of the directory
+	defaultProject bool
+}
+
+// releaseSpec is a release of lincos.yaml and the options it is deployed with
+type releaseSpec struct {
+	Name        string            `json:"name"`
+	Chart       string            `json:"chart"`
+	Version     string            `json:"version,omitempty"`
+	Namespace   string            `json:"namespace,omitempty"`
+	KubeContext string            `json:"kubeContext,omitempty"`
+	Values      []string          `json:"values,omitempty"`
+	Set         []string          `json:"set,omitempty"`
+	SetString   []string          `json:"setString,omitempty"`
+	Needs       []string          `json:"needs,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	deployOptions
+
+	// project of the lincos.yaml the release is declared in
+	project string
+}
+
+// lincosFileOptions are the flags of commands working on the releases of a
+// lincos.yaml
+type lincosFileOptions struct {
+	file        string
+	environment string
+	selector    string
+}
+
+func addLincosFileFlags(f *pflag.FlagSet, o *lincosFileOptions) {
+	f.StringVarP(&o.file, "file", "f", defaultLincosFile, "path to the lincos.yaml file")
+	f.StringVarP(&o.environment, "environment", "e", "", "environment of the lincos.yaml file to use")
+	f.StringVarP(&o.selector, "selector", "l", "", "only use releases matching the label selector (e.g. tier=backend,team!=payments,env in (dev,staging))")
+}
+
+// environmentSpec overrides releases when deploying to one environment
+type environmentSpec struct {
+	KubeContext string                      `json:"kubeContext,omitempty"`
+	Releases    map[string]*releaseOverride `json:"releases,omitempty"`
+}
+
+// releaseOverride changes a release in an environment. Values files and set
+// values are added after the ones of the release, so they win.
+type releaseOverride struct {
+	Version     string   `json:"version,omitempty"`
+	Namespace   string   `json:"namespace,omitempty"`
+	KubeContext string   `json:"kubeContext,omitempty"`
+	Values      []string `json:"values,omitempty"`
+	Set         []string `json:"set,omitempty"`
+	SetString   []string `json:"setString,omitempty"`
+}
+
+// deployOptions are the deploy flags which can be given per release
+type deployOptions struct {
+	CreateNamespace  bool             `json:"createNamespace,omitempty"`
+	Wait             bool             `json:"wait,omitempty"`
+	Atomic           bool             `json:"atomic,omitempty"`
+	Timeout          *metav1.Duration `json:"timeout,omitempty"`
+	Force            bool             `json:"force,omitempty"`
+	DisableHooks     bool             `json:"disableHooks,omitempty"`
+	ResetValues      bool             `json:"resetValues,omitempty"`
+	ReuseValues      bool             `json:"reuseValues,omitempty"`
+	CleanupOnFail    bool             `json:"cleanupOnFail,omitempty"`
+	MaxHistory       *int             `json:"historyMax,omitempty"`
+	DependencyUpdate bool             `json:"dependencyUpdate,omitempty"`
+	Verify           bool             `json:"verify,omitempty"`
+	Description      string           `json:"description,omitempty"`
+}
+
+// loadLincosFile reads and validates a lincos.yaml. Relative values files are
+// resolved against the directory of the file.
+func loadLincosFile(file string) (*lincosFile, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	lf := &lincosFile{}
+	if err := yaml.UnmarshalStrict(data, lf); err != nil {
+		return nil, errors.Wrapf(err, "invalid lincos file %s", file)
+	}
+
+	dir := filepath.Dir(file)
+	if lf.Project == "" {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, err
+		}
+		lf.Project = filepath.Base(abs)
+		lf.defaultProject = true
+	}
+	if strings.ContainsAny(lf.Project, "()") {
+		return nil, errors.Errorf("%s: project %q must not contain parentheses", file, lf.Project)
+	}
+
+	seen := map[string]bool{}
+	for i, r := range lf.Releases {
+		r.project = lf.Project
+		switch {
+		case r.Name == "":
+			return nil, errors.Errorf("%s: release %d has no name", file, i+1)
+		case r.Chart == "":
+			return nil, errors.Errorf("%s: release %q has no chart", file, r.Name)
+		case seen[r.Name]:
+			return nil, errors.Errorf("%s: release %q is declared more than once", file, r.Name)
+		}
+		seen[r.Name] = true
+
+		resolveValuesFiles(dir, r.Values)
+	}
+	for env, e := range lf.Environments {
+		for name, o := range e.Releases {
+			if !seen[name] {
+				return nil, errors.Errorf("%s: environment %q overrides unknown release %q", file, env, name)
+			}
+			resolveValuesFiles(dir, o.Values)
+		}
+	}
+	for _, r := range lf.Releases {
+		for _, n := range r.Needs {
+			if !seen[n] {
+				return nil, errors.Errorf("%s: release %q needs unknown release %q", file, r.Name, n)
+			}
+		}
+	}
+	if cycle := findNeedsCycle(lf.Releases); cycle != nil {
+		return nil, errors.Errorf("%s: releases need each other in a cycle: %s", file, strings.Join(cycle, " -> "))
+	}
+	return lf, nil
+}
+
+func resolveValuesFiles(dir string, files []string) {
+	for i, v := range files {
+		if !filepath.IsAbs(v) && !strings.Contains(v, "://") {
+			files[i] = filepath.Join(dir, v)
+		}
+	}
+}
+
+// releasesFor returns the releases with the overrides of an environment
+// applied, the releases as declared when env is empty
+func (lf *lincosFile) releasesFor(env string) ([]*releaseSpec, error) {
+	if env == "" {
+		return lf.Releases, nil
+	}
+	e, ok := lf.Environments[env]
+	if !ok {
+		return nil, errors.Errorf("environment %q is not declared", env)
+	}
+
+	releases := make([]*releaseSpec, 0, len(lf.Releases))
+	for _, r := range lf.Releases {
+		rs := *r
+		if e.KubeContext != "" {
+			rs.KubeContext = e.KubeContext
+		}
+		if o, ok := e.Releases[r.Name]; ok {
+			if o.Version != "" {
+				rs.Version = o.Version
+			}
+			if o.Namespace != "" {
+				rs.Namespace = o.Namespace
+			}
+			if o.KubeContext != "" {
+				rs.KubeContext = o.KubeContext
+			}
+			rs.Values = append(append([]string{}, r.Values...), o.Values...)
+			rs.Set = append(append([]string{}, r.Set...), o.Set...)
+			rs.SetString = append(append([]string{}, r.SetString...), o.SetString...)
+		}
+		releases = append(releases, &rs)
+	}
+	return releases, nil
+}
+
+// releases loads the lincos.yaml and returns the releases of the environment
+// matching the selector, with their values templates rendered. The returned
+// function removes the rendered values files.
+func (o *lincosFileOptions) releases() ([]*releaseSpec, func(), error) {
+	lf, err := loadLincosFile(o.file)
+	if err != nil {
+		return nil, nil, err
+	}
+	releases, err := lf.releasesFor(o.environment)
+	if err != nil {
+		return nil, nil, err
+	}
+	if releases, err = selectReleases(releases, o.selector); err != nil {
+		return nil, nil, err
+	}
+
+	dir, err := ioutil.TempDir("", "lincos-values")
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+	if err := renderValuesTemplates(releases, o.environment, dir); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	return releases, cleanup, nil
+}
+
+// selectReleases returns the releases matching a label selector. Every
+// release also carries its name as the label "name". Needs on releases which
+// are not selected are dropped, those releases are left as they are.
+func selectReleases(releases []*releaseSpec, selector string) ([]*releaseSpec, error) {
+	if selector == "" {
+		return releases, nil
+	}
+	sel, err := labels.Parse(selector)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid selector")
+	}
+
+	selected := []*releaseSpec{}
+	names := map[string]bool{}
+	for _, r := range releases {
+		set := labels.Set{"name": r.Name}
+		for k, v := range r.Labels {
+			set[k] = v
+		}
+		if sel.Matches(set) {
+			selected = append(selected, r)
+			names[r.Name] = true
+		}
+	}
+	if len(selected) == 0 {
+		return nil, errors.Errorf("no release matches the selector %q", selector)
+	}
+
+	for i, r := range selected {
+		needs := []string{}
+		for _, n := range r.Needs {
+			if names[n] {
+				needs = append(needs, n)
+			} else {
+				debug("Release \"%s\" needs \"%s\" which is not selected", r.Name, n)
+			}
+		}
+		rs := *r
+		rs.Needs = needs
+		selected[i] = &rs
+	}
+	debug("Selector \"%s\" matches %d of %d releases", selector, len(selected), len(releases))
+	return selected, nil
+}
+
+// renderValuesTemplates renders the values files ending in .gotmpl of the
+// releases into dir. Templates see the environment and the release:
+//
+//	replicas: {{ if eq .Environment.Name "prod" }}3{{ else }}1{{ end }}
+//	host: {{ .Release.Name }}.{{ .Environment.Name }}.example.com
+func renderValuesTemplates(releases []*releaseSpec, env, dir string) error {
+	for _, r := range releases {
+		data := map[string]interface{}{
+			"Environment": map[string]interface{}{"Name": env},
+			"Release": map[string]interface{}{
+				"Name":      r.Name,
+				"Namespace": r.Namespace,
+			},
+		}
+
+		files := make([]string, 0, len(r.Values))
+		for i, v := range r.Values {
+			if !strings.HasSuffix(v, ".gotmpl") {
+				files = append(files, v)
+				continue
+			}
+			tpl, err := template.New(filepath.Base(v)).Funcs(sprig.TxtFuncMap()).ParseFiles(v)
+			if err != nil {
+				return errors.Wrapf(err, "release %s", r.Name)
+			}
+			var buf bytes.Buffer
+			if err := tpl.Execute(&buf, data); err != nil {
+				return errors.Wrapf(err, "release %s", r.Name)
+			}
+			rendered := filepath.Join(dir, fmt.Sprintf("%s-%d-%s", r.Name, i, strings.TrimSuffix(filepath.Base(v), ".gotmpl")))
+			if err := ioutil.WriteFile(rendered, buf.Bytes(), 0600); err != nil {
+				return err
+			}
+			debug("Values template \"%s\" of release \"%s\" rendered to \"%s\"", v, r.Name, rendered)
+			files = append(files, rendered)
+		}
+		r.Values = files
+	}
+	return nil
+}
+
+// findNeedsCycle walks the needs of the releases depth first and returns the
+// first cycle it finds, nil when the releases form a DAG
+func findNeedsCycle(releases []*releaseSpec) []string {
+	needs := map[string][]string{}
+	for _, r := range releases {
+		needs[r.Name] = r.Needs
+	}
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := map[string]int{}
+	var path []string
+	var visit func(name string) []string
+	visit = func(name string) []string {
+		switch state[name] {
+		case visited:
+			return nil
+		case visiting:
+			for i, n := range path {
+				if n == name {
+					return append(append([]string{}, path[i:]...), name)
+				}
+			}
+		}
+		state[name] = visiting
+		path = append(path, name)
+		for _, n := range needs[name] {
+			if cycle := visit(n); cycle != nil {
+				return cycle
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = visited
+		return nil
+	}
+
+	for _, r := range releases {
+		if cycle := visit(r.Name); cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
+
+// valueOptions returns the values of the release as the --values and --set flags would
+func (r *releaseSpec) valueOptions() *values.Options {
+	return &values.Options{
+		ValueFiles:   r.Values,
+		Values:       r.Set,
+		StringValues: r.SetString,
+	}
+}
+
+// actionConfig initializes an action configuration for the kube context and
+// namespace of the release and returns it with the namespace
+func (r *releaseSpec) actionConfig() (*action.Configuration, string, error) {
+	kubeCfg, namespace := kubeConfig(r.KubeContext, r.Namespace)
+	cfg := new(action.Configuration)
+	if err := cfg.Init(kubeCfg, namespace, os.Getenv("HELM_DRIVER"), log.Printf); err != nil {
+		return nil, "", err
+	}
+	return cfg, namespace, nil
+}
+
+// clients builds install and upgrade clients with the same defaults as the deploy flags
+func (r *releaseSpec) clients(cfg *action.Configuration) (*action.Install, *action.Upgrade) {
+	client := action.NewInstall(cfg)
+	clientUpgrade := action.NewUpgrade(cfg)
+
+	client.Namespace = r.Namespace
+	clientUpgrade.Namespace = r.Namespace
+	clientUpgrade.Version = r.Version
+	// The keyring is left to the provenance policy
+	clientUpgrade.Verify = r.Verify
+
+	client.Timeout = 300 * time.Second
+	if r.Timeout != nil {
+		client.Timeout = r.Timeout.Duration
+	}
+	clientUpgrade.Timeout = client.Timeout
+	clientUpgrade.MaxHistory = 10
+	if r.MaxHistory != nil {
+		clientUpgrade.MaxHistory = *r.MaxHistory
+	}
+
+	client.CreateNamespace = r.CreateNamespace
+	client.Wait = r.Wait || r.Atomic
+	client.Atomic = r.Atomic
+	client.DisableHooks = r.DisableHooks
+	client.DependencyUpdate = r.DependencyUpdate
+	client.Description = managedDescription(r.Description, "Install complete", r.project)
+	clientUpgrade.Wait = r.Wait || r.Atomic
+	clientUpgrade.Atomic = r.Atomic
+	clientUpgrade.Force = r.Force
+	clientUpgrade.DisableHooks = r.DisableHooks
+	clientUpgrade.ResetValues = r.ResetValues
+	clientUpgrade.ReuseValues = r.ReuseValues
+	clientUpgrade.CleanupOnFail = r.CleanupOnFail
+	clientUpgrade.Description = managedDescription(r.Description, "Upgrade complete", r.project)
+
+	return client, clientUpgrade
+}
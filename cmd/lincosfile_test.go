@@ -0,0 +1,364 @@
+/*
+Copyright © 2020 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+const testLincosFile = `
+project: shop
+releases:
+- name: api
+  chart: shop/api
+  version: ^1.2
+  namespace: web
+  labels:
+    tier: backend
+  values:
+  - values/api.yaml
+  - values/api.yaml.gotmpl
+  set:
+  - image.tag=1.2.3
+  needs:
+  - db
+- name: db
+  chart: shop/db
+environments:
+  staging:
+    kubeContext: staging
+    releases:
+      api:
+        version: 1.3.0
+        values:
+        - values/api-staging.yaml
+        set:
+        - replicas=1
+  prod:
+    releases:
+      api:
+        namespace: web-prod
+        kubeContext: prod-eu
+        setString:
+        - region=eu
+`
+
+// writeTestLincosFile writes a lincos.yaml with its values files into dir
+func writeTestLincosFile(t *testing.T, dir string) string {
+	files := map[string]string{
+		"lincos.yaml":             testLincosFile,
+		"values/api.yaml":         "replicas: 2\n",
+		"values/api-staging.yaml": "replicas: 1\n",
+		"values/api.yaml.gotmpl":  "host: {{ .Release.Name }}.{{ .Environment.Name }}.example.com\n",
+	}
+	for name, data := range files {
+		file := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(file, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return filepath.Join(dir, "lincos.yaml")
+}
+
+func TestReleasesForEnvironment(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lincos-file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	lf, err := loadLincosFile(writeTestLincosFile(t, dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := func(name string) string {
+		return filepath.Join(dir, "values", name)
+	}
+	db := &releaseSpec{Name: "db", Chart: "shop/db", project: "shop"}
+
+	tests := []struct {
+		environment string
+		expected    []*releaseSpec
+	}{
+		{
+			environment: "",
+			expected: []*releaseSpec{
+				{
+					Name:      "api",
+					Chart:     "shop/api",
+					Version:   "^1.2",
+					Namespace: "web",
+					Values:    []string{values("api.yaml"), values("api.yaml.gotmpl")},
+					Set:       []string{"image.tag=1.2.3"},
+					Needs:     []string{"db"},
+					Labels:    map[string]string{"tier": "backend"},
+					project:   "shop",
+				},
+				db,
+			},
+		},
+		{
+			environment: "staging",
+			expected: []*releaseSpec{
+				{
+					Name:        "api",
+					Chart:       "shop/api",
+					Version:     "1.3.0",
+					Namespace:   "web",
+					KubeContext: "staging",
+					Values:      []string{values("api.yaml"), values("api.yaml.gotmpl"), values("api-staging.yaml")},
+					Set:         []string{"image.tag=1.2.3", "replicas=1"},
+					SetString:   []string{},
+					Needs:       []string{"db"},
+					Labels:      map[string]string{"tier": "backend"},
+					project:     "shop",
+				},
+				{Name: "db", Chart: "shop/db", KubeContext: "staging", project: "shop"},
+			},
+		},
+		{
+			environment: "prod",
+			expected: []*releaseSpec{
+				{
+					Name:        "api",
+					Chart:       "shop/api",
+					Version:     "^1.2",
+					Namespace:   "web-prod",
+					KubeContext: "prod-eu",
+					Values:      []string{values("api.yaml"), values("api.yaml.gotmpl")},
+					Set:         []string{"image.tag=1.2.3"},
+					SetString:   []string{"region=eu"},
+					Needs:       []string{"db"},
+					Labels:      map[string]string{"tier": "backend"},
+					project:     "shop",
+				},
+				db,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		releases, err := lf.releasesFor(tt.environment)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(releases, tt.expected) {
+			for i := range releases {
+				t.Logf("%+v", *releases[i])
+			}
+			t.Errorf("environment %q: unexpected releases", tt.environment)
+		}
+	}
+
+	if _, err := lf.releasesFor("dev"); err == nil || !strings.Contains(err.Error(), `environment "dev" is not declared`) {
+		t.Errorf("expected an unknown environment to fail, got %v", err)
+	}
+}
+
+func TestRenderValuesTemplates(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lincos-file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, env := range []string{"staging", "prod"} {
+		o := &lincosFileOptions{file: writeTestLincosFile(t, dir), environment: env, selector: "name=api"}
+		releases, cleanup, err := o.releases()
+		if err != nil {
+			t.Fatal(err)
+		}
+		values := releases[0].Values
+		rendered, err := ioutil.ReadFile(values[1])
+		cleanup()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if values[0] != filepath.Join(dir, "values", "api.yaml") || strings.HasSuffix(values[1], ".gotmpl") {
+			t.Errorf("%s: expected only the values template to be replaced, got %v", env, values)
+		}
+		if expected := "host: api." + env + ".example.com\n"; string(rendered) != expected {
+			t.Errorf("%s: expected %q, got %q", env, expected, rendered)
+		}
+	}
+}
+
+func TestEnvironmentKubeContextKeepsNamespaceFlag(t *testing.T) {
+	flags := settings.RESTClientGetter().(*genericclioptions.ConfigFlags)
+	defer func(namespace string) { *flags.Namespace = namespace }(*flags.Namespace)
+	*flags.Namespace = "shop"
+
+	dir, err := ioutil.TempDir("", "lincos-file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	lf, err := loadLincosFile(writeTestLincosFile(t, dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	releases, err := lf.releasesFor("staging")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{"api": "web", "db": "shop"}
+	for _, r := range releases {
+		getter, namespace := kubeConfig(r.KubeContext, r.Namespace)
+		if namespace != expected[r.Name] {
+			t.Errorf("expected release %s in namespace %s, got %s", r.Name, expected[r.Name], namespace)
+		}
+		if ns := *getter.(*genericclioptions.ConfigFlags).Namespace; ns != expected[r.Name] {
+			t.Errorf("expected the kube config of release %s to use namespace %s, got %s", r.Name, expected[r.Name], ns)
+		}
+		if ctx := *getter.(*genericclioptions.ConfigFlags).Context; ctx != "staging" {
+			t.Errorf("expected release %s in kube context staging, got %s", r.Name, ctx)
+		}
+	}
+}
+
+func TestFindNeedsCycle(t *testing.T) {
+	tests := []struct {
+		name     string
+		releases []*releaseSpec
+		expected []string
+	}{
+		{
+			name: "no needs",
+			releases: []*releaseSpec{
+				{Name: "a"},
+				{Name: "b"},
+			},
+		},
+		{
+			name: "diamond",
+			releases: []*releaseSpec{
+				{Name: "web", Needs: []string{"api", "cache"}},
+				{Name: "api", Needs: []string{"db"}},
+				{Name: "cache", Needs: []string{"db"}},
+				{Name: "db"},
+			},
+		},
+		{
+			name: "self",
+			releases: []*releaseSpec{
+				{Name: "a", Needs: []string{"a"}},
+			},
+			expected: []string{"a", "a"},
+		},
+		{
+			name: "cycle",
+			releases: []*releaseSpec{
+				{Name: "web", Needs: []string{"api"}},
+				{Name: "api", Needs: []string{"db"}},
+				{Name: "db", Needs: []string{"api"}},
+			},
+			expected: []string{"api", "db", "api"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cycle := findNeedsCycle(tt.releases)
+			if !reflect.DeepEqual(cycle, tt.expected) {
+				t.Errorf("expected cycle %v, got %v", tt.expected, cycle)
+			}
+		})
+	}
+}
+
+func TestSelectReleases(t *testing.T) {
+	releases := []*releaseSpec{
+		{Name: "db", Labels: map[string]string{"tier": "data", "team": "platform"}},
+		{Name: "api", Labels: map[string]string{"tier": "backend", "team": "shop"}, Needs: []string{"db"}},
+		{Name: "web", Labels: map[string]string{"tier": "frontend", "team": "shop"}, Needs: []string{"api", "db"}},
+		{Name: "worker", Labels: map[string]string{"tier": "backend", "team": "payments"}, Needs: []string{"db"}},
+	}
+
+	tests := []struct {
+		selector string
+		expected map[string][]string
+		err      string
+	}{
+		{
+			selector: "",
+			expected: map[string][]string{"db": nil, "api": {"db"}, "web": {"api", "db"}, "worker": {"db"}},
+		},
+		{
+			selector: "tier=backend",
+			expected: map[string][]string{"api": {}, "worker": {}},
+		},
+		{
+			selector: "team!=payments",
+			expected: map[string][]string{"db": {}, "api": {"db"}, "web": {"api", "db"}},
+		},
+		{
+			selector: "tier in (frontend,backend),team=shop",
+			expected: map[string][]string{"api": {}, "web": {"api"}},
+		},
+		{
+			selector: "tier notin (frontend,backend)",
+			expected: map[string][]string{"db": {}},
+		},
+		{
+			selector: "name=web",
+			expected: map[string][]string{"web": {}},
+		},
+		{
+			selector: "!team",
+			err:      `no release matches the selector "!team"`,
+		},
+		{
+			selector: "tier in (",
+			err:      "invalid selector",
+		},
+	}
+
+	for _, tt := range tests {
+		selected, err := selectReleases(releases, tt.selector)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%q: expected error %q, got %v", tt.selector, tt.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%q: %v", tt.selector, err)
+		}
+		needs := map[string][]string{}
+		for _, r := range selected {
+			needs[r.Name] = r.Needs
+		}
+		if !reflect.DeepEqual(needs, tt.expected) {
+			t.Errorf("%q: expected releases with needs %v, got %v", tt.selector, tt.expected, needs)
+		}
+	}
+
+	// Dropping needs must not change the declared releases
+	if !reflect.DeepEqual(releases[2].Needs, []string{"api", "db"}) {
+		t.Errorf("expected the needs of the declared release to be kept, got %v", releases[2].Needs)
+	}
+}
@@ -0,0 +1,199 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/cli/output"
+	"helm.sh/helm/v3/pkg/cli/values"
+	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/lint/support"
+)
+
+var longLintHelp = `
+This command takes a path to a chart and runs a series of tests to verify that
+the chart is well-formed. Several chart paths can be linted at once.
+
+If the linter encounters things that will cause the chart to fail installation,
+it will emit [ERROR] messages. If it encounters issues that break with convention
+or recommendation, it will emit [WARNING] messages.
+`
+
+var lintSeverities = map[int]string{
+	support.UnknownSev: "UNKNOWN",
+	support.InfoSev:    "INFO",
+	support.WarningSev: "WARNING",
+	support.ErrorSev:   "ERROR",
+}
+
+func newLintCmd(out io.Writer) *cobra.Command {
+	client := action.NewLint()
+	valueOpts := &values.Options{}
+	var outfmt output.Format
+	cmd := &cobra.Command{
+		Use:   "lint [chart path]...",
+		Short: "Examine charts for possible issues",
+		Long:  longLintHelp,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			paths := []string{"."}
+			if len(args) > 0 {
+				paths = args
+			}
+			results, err := RunLint(paths, client, valueOpts)
+			if err != nil {
+				return err
+			}
+			if err := outfmt.Write(out, results); err != nil {
+				return err
+			}
+			if failed := results.failed(); failed > 0 {
+				return errors.Errorf("%d chart(s) linted, %d chart(s) failed", len(results), failed)
+			}
+			return nil
+		},
+	}
+
+	f := cmd.Flags()
+	f.BoolVar(&client.Strict, "strict", false, "fail on lint warnings")
+	f.BoolVar(&client.WithSubcharts, "with-subcharts", false, "lint dependent charts")
+	addValueOptionsFlags(f, valueOpts)
+	bindOutputFlag(cmd, &outfmt)
+
+	return cmd
+}
+
+type lintMessage struct {
+	Severity string `json:"severity"`
+	Path     string `json:"path"`
+	Message  string `json:"message"`
+}
+
+type lintChartResult struct {
+	Chart    string        `json:"chart"`
+	Failed   bool          `json:"failed"`
+	Messages []lintMessage `json:"messages"`
+	Errors   []string      `json:"errors,omitempty"`
+}
+
+type lintResults []lintChartResult
+
+func (r lintResults) failed() int {
+	failed := 0
+	for _, res := range r {
+		if res.Failed {
+			failed++
+		}
+	}
+	return failed
+}
+
+func (r lintResults) WriteJSON(out io.Writer) error {
+	return output.EncodeJSON(out, r)
+}
+
+func (r lintResults) WriteYAML(out io.Writer) error {
+	return output.EncodeYAML(out, r)
+}
+
+func (r lintResults) WriteTable(out io.Writer) error {
+	for _, res := range r {
+		fmt.Fprintf(out, "==> Linting %s\n", res.Chart)
+		// All the Errors that are generated by a chart
+		// that failed a lint will be included in the
+		// messages so we only need to print the Errors
+		// if there are no messages.
+		if len(res.Messages) == 0 {
+			for _, err := range res.Errors {
+				fmt.Fprintf(out, "Error %s\n", err)
+			}
+		}
+		for _, msg := range res.Messages {
+			fmt.Fprintf(out, "[%s] %s: %s\n", msg.Severity, msg.Path, msg.Message)
+		}
+		fmt.Fprintln(out)
+	}
+	if r.failed() == 0 {
+		fmt.Fprintf(out, "%d chart(s) linted, 0 chart(s) failed\n", len(r))
+	}
+	return nil
+}
+
+//Lint charts
+func RunLint(paths []string, client *action.Lint, valueOpts *values.Options) (lintResults, error) {
+
+	setLogger()
+	if client.WithSubcharts {
+		paths = append(paths, subchartPaths(paths)...)
+	}
+
+	client.Namespace = settings.Namespace()
+	vals, err := valueOpts.MergeValues(getter.All(settings))
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(lintResults, 0, len(paths))
+	for _, path := range paths {
+		debug("We lint chart \"%s\"", path)
+		result := client.Run([]string{path}, vals)
+
+		res := lintChartResult{
+			Chart:    path,
+			Failed:   len(result.Errors) != 0,
+			Messages: make([]lintMessage, 0, len(result.Messages)),
+		}
+		for _, msg := range result.Messages {
+			res.Messages = append(res.Messages, lintMessage{
+				Severity: lintSeverities[msg.Severity],
+				Path:     msg.Path,
+				Message:  msg.Err.Error(),
+			})
+		}
+		for _, err := range result.Errors {
+			res.Errors = append(res.Errors, err.Error())
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+// subchartPaths finds the charts vendored in the charts/ directory
+func subchartPaths(paths []string) []string {
+	var subcharts []string
+	for _, p := range paths {
+		filepath.Walk(filepath.Join(p, "charts"), func(path string, info os.FileInfo, err error) error {
+			if info != nil {
+				if info.Name() == "Chart.yaml" {
+					subcharts = append(subcharts, filepath.Dir(path))
+				} else if strings.HasSuffix(path, ".tgz") || strings.HasSuffix(path, ".tar.gz") {
+					subcharts = append(subcharts, path)
+				}
+			}
+			return nil
+		})
+	}
+	return subcharts
+}
@@ -0,0 +1,212 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/gosuri/uitable"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"helm.sh/helm/v3/cmd/helm/require"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/cli/output"
+	"helm.sh/helm/v3/pkg/release"
+)
+
+var listHelp = `
+This command lists all of the releases for a specified namespace (uses
+current namespace context if namespace not specified).
+
+By default, it lists only releases that are deployed or failed. Flags like
+'--uninstalled' and '--all' will alter this behavior. Such flags can be combined:
+'--uninstalled --failed'.
+
+By default, items are sorted alphabetically. Use the '-d' flag to sort by
+release date.
+
+If the --filter flag is provided, it will be treated as a filter. Filters are
+regular expressions (Perl compatible) that are applied to the list of releases.
+Only items that match the filter will be returned.
+`
+
+func newListCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
+	client := action.NewList(cfg)
+	var outfmt output.Format
+	cmd := &cobra.Command{
+		Use:     "list",
+		Short:   "List releases",
+		Long:    listHelp,
+		Aliases: []string{"ls"},
+		Args:    require.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			results, err := RunList(cfg, client)
+			if err != nil {
+				return err
+			}
+			return outfmt.Write(out, newReleaseListWriter(results))
+		},
+	}
+
+	addListFlags(cmd.Flags(), client)
+	bindOutputFlag(cmd, &outfmt)
+
+	return cmd
+}
+
+func addListFlags(f *pflag.FlagSet, client *action.List) {
+	f.BoolVarP(&client.ByDate, "date", "d", false, "sort by release date")
+	f.BoolVarP(&client.SortReverse, "reverse", "r", false, "reverse the sort order")
+	f.BoolVarP(&client.All, "all", "a", false, "show all releases without any filter applied")
+	f.BoolVar(&client.Uninstalled, "uninstalled", false, "show uninstalled releases (if 'lincos helm uninstall --keep-history' was used)")
+	f.BoolVar(&client.Superseded, "superseded", false, "show superseded releases")
+	f.BoolVar(&client.Uninstalling, "uninstalling", false, "show releases that are currently being uninstalled")
+	f.BoolVar(&client.Deployed, "deployed", false, "show deployed releases. If no other is specified, this will be automatically enabled")
+	f.BoolVar(&client.Failed, "failed", false, "show failed releases")
+	f.BoolVar(&client.Pending, "pending", false, "show pending releases")
+	f.BoolVarP(&client.AllNamespaces, "all-namespaces", "A", false, "list releases across all namespaces")
+	f.IntVarP(&client.Limit, "max", "m", 256, "maximum number of releases to fetch")
+	f.IntVar(&client.Offset, "offset", 0, "next release name in the list, used to offset from start value")
+	f.StringVarP(&client.Filter, "filter", "f", "", "a regular expression (Perl compatible). Any releases that match the expression will be included in the results")
+}
+
+//List releases
+func RunList(cfg *action.Configuration, client *action.List) ([]*release.Release, error) {
+
+	setLogger()
+	namespace := settings.Namespace()
+	if client.AllNamespaces {
+		namespace = ""
+	}
+	debug("We list releases in namespace \"%s\"", namespace)
+	if err := cfg.Init(settings.RESTClientGetter(), namespace, os.Getenv("HELM_DRIVER"), log.Printf); err != nil {
+		return nil, err
+	}
+
+	client.SetStateMask()
+	return client.Run()
+}
+
+type releaseElement struct {
+	Name       string `json:"name"`
+	Namespace  string `json:"namespace"`
+	Revision   string `json:"revision"`
+	Updated    string `json:"updated"`
+	Status     string `json:"status"`
+	Chart      string `json:"chart"`
+	AppVersion string `json:"app_version"`
+}
+
+type releaseListWriter struct {
+	releases []releaseElement
+}
+
+func newReleaseListWriter(releases []*release.Release) *releaseListWriter {
+	// Initialize the array so no results returns an empty array instead of null
+	elements := make([]releaseElement, 0, len(releases))
+	for _, r := range releases {
+		element := releaseElement{
+			Name:       r.Name,
+			Namespace:  r.Namespace,
+			Revision:   strconv.Itoa(r.Version),
+			Status:     r.Info.Status.String(),
+			Chart:      formatChartname(r.Chart),
+			AppVersion: formatAppVersion(r.Chart),
+		}
+		t := "-"
+		if tspb := r.Info.LastDeployed; !tspb.IsZero() {
+			t = tspb.String()
+		}
+		element.Updated = t
+		elements = append(elements, element)
+	}
+	return &releaseListWriter{elements}
+}
+
+func (r *releaseListWriter) WriteTable(out io.Writer) error {
+	debug("RunList: WriteTable")
+	table := uitable.New()
+	table.AddRow("NAME", "NAMESPACE", "REVISION", "UPDATED", "STATUS", "CHART", "APP VERSION")
+	for _, r := range r.releases {
+		table.AddRow(r.Name, r.Namespace, r.Revision, r.Updated, r.Status, r.Chart, r.AppVersion)
+	}
+	return output.EncodeTable(out, table)
+}
+
+func (r *releaseListWriter) WriteJSON(out io.Writer) error {
+	debug("RunList: WriteJSON")
+	return output.EncodeJSON(out, r.releases)
+}
+
+func (r *releaseListWriter) WriteYAML(out io.Writer) error {
+	debug("RunList: WriteYAML")
+	return output.EncodeYAML(out, r.releases)
+}
+
+
+// compListReleases provides dynamic auto-completion for release names
+func compListReleases(toComplete string, cfg *action.Configuration) ([]string, cobra.ShellCompDirective) {
+	cobra.CompDebugln(fmt.Sprintf("compListReleases with toComplete %s", toComplete), settings.Debug)
+
+	if err := initActionConfig(cfg); err != nil {
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+	client := action.NewList(cfg)
+	client.All = true
+	client.Limit = 0
+	client.Filter = fmt.Sprintf("^%s", toComplete)
+
+	client.SetStateMask()
+	results, err := client.Run()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+
+	var choices []string
+	for _, res := range results {
+		choices = append(choices, res.Name)
+	}
+	return choices, cobra.ShellCompDirectiveNoFileComp
+}
+
+// compReleaseArg completes the release name of commands taking a single release
+func compReleaseArg(cfg *action.Configuration) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) != 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return compListReleases(toComplete, cfg)
+	}
+}
+
+// compReleaseChartArgs completes [release name] [chart] arguments
+func compReleaseChartArgs(cfg *action.Configuration) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		switch len(args) {
+		case 0:
+			return compListReleases(toComplete, cfg)
+		case 1:
+			return compListCharts(toComplete, true)
+		}
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+}
@@ -0,0 +1,347 @@
+/*
+Copyright © 2020 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/gosuri/uitable"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/cli/output"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+const defaultLockFile = "lincos.lock"
+
+const lockDesc = `
+Resolve the charts of releases and record the result in a lock file.
+
+Every release is recorded with its chart reference, version constraint,
+resolved chart version, repository URL and the digest of the chart archive,
+of the files of a chart directory or the commit of a git chart. Deploy with
+'--locked' to fail whenever resolving a chart gives a different result than
+the lock file, the chart which was checked is the one deployed.
+
+Releases are given as RELEASE=CHART and added to the lock file. Without
+arguments every release of the lock file is resolved again within its
+version constraint.
+
+    $ lincos lock api=stable/nginx --chart-version api='^1.2'
+    $ lincos lock
+    $ lincos helm deploy api --locked
+`
+
+// lockFile records how the chart of every release was resolved
+type lockFile struct {
+	Releases map[string]*lockEntry `json:"releases"`
+}
+
+type lockEntry struct {
+	Chart      string `json:"chart"`
+	Constraint string `json:"constraint,omitempty"`
+	Version    string `json:"version"`
+	Repository string `json:"repository,omitempty"`
+	Digest     string `json:"digest,omitempty"`
+}
+
+type lockOptions struct {
+	lockFile      string
+	chartVersions []string
+}
+
+type lockChange struct {
+	Release string `json:"release"`
+	*lockEntry
+	Previous string `json:"previous,omitempty"`
+}
+
+func newLockCmd(out io.Writer) *cobra.Command {
+	o := &lockOptions{}
+	var outfmt output.Format
+
+	cmd := &cobra.Command{
+		Use:   "lock [RELEASE=CHART...]",
+		Short: "Resolve charts into a lock file",
+		Long:  lockDesc,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			changes, err := RunLock(args, o)
+			if err != nil {
+				return err
+			}
+			return outfmt.Write(out, &lockWriter{changes})
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringVar(&o.lockFile, "lock-file", defaultLockFile, "path to the lock file")
+	f.StringArrayVar(&o.chartVersions, "chart-version", []string{}, "chart version constraint of a release as RELEASE=CONSTRAINT (can specify multiple)")
+	bindOutputFlag(cmd, &outfmt)
+	flags := cmd.PersistentFlags()
+	settings.AddFlags(flags)
+
+	return cmd
+}
+
+//Resolve charts of releases into the lock file
+func RunLock(args []string, o *lockOptions) ([]lockChange, error) {
+
+	setLogger()
+	lock, err := loadLockFile(o.lockFile)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if lock == nil {
+		lock = &lockFile{Releases: map[string]*lockEntry{}}
+	}
+
+	// Without arguments every release is resolved again, otherwise the given
+	// releases and those of which the version constraint changed
+	selected := map[string]bool{}
+	for _, arg := range args {
+		name, ref, err := splitReleaseArg(arg)
+		if err != nil {
+			return nil, err
+		}
+		e, ok := lock.Releases[name]
+		if !ok || e.Chart != ref {
+			e = &lockEntry{Chart: ref}
+			lock.Releases[name] = e
+		}
+		selected[name] = true
+	}
+	for _, arg := range o.chartVersions {
+		name, constraint, err := splitReleaseArg(arg)
+		if err != nil {
+			return nil, err
+		}
+		e, ok := lock.Releases[name]
+		if !ok {
+			return nil, errors.Errorf("chart version given for unknown release %q", name)
+		}
+		e.Constraint = constraint
+		selected[name] = true
+	}
+	if len(args) == 0 {
+		for name := range lock.Releases {
+			selected[name] = true
+		}
+	}
+	names := sortedKeys(selected)
+
+	changes := []lockChange{}
+	for _, name := range names {
+		e := lock.Releases[name]
+		resolved, _, err := resolveLockEntry(&action.ChartPathOptions{Version: e.Constraint}, e.Chart)
+		if err != nil {
+			return nil, errors.Wrapf(err, "release %s", name)
+		}
+		resolved.Constraint = e.Constraint
+		changes = append(changes, lockChange{name, resolved, e.Version})
+		lock.Releases[name] = resolved
+	}
+
+	return changes, saveLockFile(o.lockFile, lock)
+}
+
+func loadLockFile(file string) (*lockFile, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	lock := &lockFile{}
+	if err := yaml.UnmarshalStrict(data, lock); err != nil {
+		return nil, errors.Wrapf(err, "invalid lock file %s", file)
+	}
+	if lock.Releases == nil {
+		lock.Releases = map[string]*lockEntry{}
+	}
+	return lock, nil
+}
+
+func saveLockFile(file string, lock *lockFile) error {
+	data, err := yaml.Marshal(lock)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, data, 0644)
+}
+
+// resolveLockEntry locates a chart and records how it was resolved. It returns
+// the reference which deploys exactly the resolved chart: the local chart, or
+// the git reference pinned to the resolved commit.
+func resolveLockEntry(c *action.ChartPathOptions, ref string) (*lockEntry, string, error) {
+	cp, commit, err := locateChart(c, ref)
+	if err != nil {
+		return nil, "", err
+	}
+	ch, err := loader.Load(cp)
+	if err != nil {
+		return nil, "", err
+	}
+
+	e := &lockEntry{
+		Chart:   ref,
+		Version: ch.Metadata.Version,
+	}
+	if e.Repository, err = chartRepositoryURL(c, ref); err != nil {
+		return nil, "", err
+	}
+	switch fi, err := os.Stat(cp); {
+	case commit != "":
+		e.Digest = "git:" + commit
+		pinned, err := pinGitReference(ref, commit)
+		return e, pinned, err
+	case err != nil:
+		return nil, "", err
+	case fi.IsDir():
+		e.Digest = "files:sha256:" + chartFilesDigest(ch)
+	default:
+		digest, err := fileDigest(cp)
+		if err != nil {
+			return nil, "", err
+		}
+		e.Digest = "sha256:" + digest
+	}
+	return e, cp, nil
+}
+
+// chartFilesDigest hashes the files of a chart directory, as loaded with its
+// .helmignore and subcharts, in the order of their names
+func chartFilesDigest(ch *chart.Chart) string {
+	files := append([]*chart.File{}, ch.Raw...)
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+	h := sha256.New()
+	for _, f := range files {
+		fmt.Fprintf(h, "%s\x00%d\x00", f.Name, len(f.Data))
+		h.Write(f.Data)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// chartRepositoryURL returns where a chart reference is resolved from,
+// empty for local charts
+func chartRepositoryURL(c *action.ChartPathOptions, ref string) (string, error) {
+	switch {
+	case c.RepoURL != "":
+		return c.RepoURL, nil
+	case strings.HasPrefix(ref, ociScheme):
+		r, err := parseOCIReference(ref, c.Version)
+		if err != nil {
+			return "", err
+		}
+		return ociScheme + r.Repo, nil
+	case strings.HasPrefix(ref, gitScheme):
+		r, err := parseGitReference(ref)
+		if err != nil {
+			return "", err
+		}
+		return r.Remote, nil
+	}
+
+	if _, err := os.Stat(ref); err == nil {
+		return "", nil
+	}
+	parts := strings.SplitN(ref, "/", 2)
+	if len(parts) != 2 {
+		return "", nil
+	}
+	rf, err := repo.LoadFile(settings.RepositoryConfig)
+	if err != nil && !isNotExist(err) {
+		return "", err
+	}
+	if e := rf.Get(parts[0]); e != nil {
+		return e.URL, nil
+	}
+	return "", nil
+}
+
+// checkLockedChart pins a deploy to the lock file and fails when the chart
+// doesn't resolve to the locked version, repository and digest. The chart
+// may be omitted from args, it is then taken from the lock file.
+func checkLockedChart(file string, args []string, c *action.ChartPathOptions) ([]string, error) {
+	lock, err := loadLockFile(file)
+	if err != nil {
+		return nil, errors.Wrap(err, "--locked requires a lock file, create it with 'lincos lock'")
+	}
+	name := args[0]
+	locked, ok := lock.Releases[name]
+	if !ok {
+		return nil, errors.Errorf("release %q is not in the lock file %s", name, file)
+	}
+	if len(args) == 1 {
+		args = append(args, locked.Chart)
+	}
+	if args[1] != locked.Chart {
+		return nil, errors.Errorf("chart %s of release %q doesn't match the locked chart %s", args[1], name, locked.Chart)
+	}
+
+	c.Version = locked.Version
+	resolved, pinned, err := resolveLockEntry(c, locked.Chart)
+	if err != nil {
+		return nil, err
+	}
+
+	var diffs []string
+	if resolved.Version != locked.Version {
+		diffs = append(diffs, fmt.Sprintf("version %s, locked %s", resolved.Version, locked.Version))
+	}
+	if resolved.Repository != locked.Repository {
+		diffs = append(diffs, fmt.Sprintf("repository %s, locked %s", resolved.Repository, locked.Repository))
+	}
+	if resolved.Digest != locked.Digest {
+		diffs = append(diffs, fmt.Sprintf("digest %s, locked %s", resolved.Digest, locked.Digest))
+	}
+	if len(diffs) > 0 {
+		return nil, errors.Errorf("chart of release %q doesn't match the lock file: %s", name, strings.Join(diffs, "; "))
+	}
+	debug("Chart of release \"%s\" matches the lock file: %s %s, deployed from %s", name, locked.Chart, locked.Version, pinned)
+	// Deploy the chart which was checked instead of resolving it again
+	return append([]string{args[0], pinned}, args[2:]...), nil
+}
+
+type lockWriter struct {
+	changes []lockChange
+}
+
+func (l *lockWriter) WriteTable(out io.Writer) error {
+	table := uitable.New()
+	table.AddRow("RELEASE", "CHART", "CONSTRAINT", "VERSION", "PREVIOUS")
+	for _, c := range l.changes {
+		table.AddRow(c.Release, c.Chart, c.Constraint, c.Version, c.Previous)
+	}
+	return output.EncodeTable(out, table)
+}
+
+func (l *lockWriter) WriteJSON(out io.Writer) error {
+	return output.EncodeJSON(out, l.changes)
+}
+
+func (l *lockWriter) WriteYAML(out io.Writer) error {
+	return output.EncodeYAML(out, l.changes)
+}
@@ -0,0 +1,121 @@
+/*
+Copyright © 2020 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chart/loader"
+)
+
+func TestChartFilesDigest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lincos-lock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	write := func(name, data string) {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	digest := func() string {
+		ch, err := loader.Load(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return chartFilesDigest(ch)
+	}
+
+	write("Chart.yaml", "apiVersion: v2\nname: api\nversion: 1.0.0\n")
+	write("values.yaml", "replicas: 1\n")
+	write("templates/cm.yaml", "kind: ConfigMap\n")
+	write(".helmignore", "notes.txt\n")
+	first := digest()
+
+	if again := digest(); again != first {
+		t.Errorf("expected the same digest for the same files, got %s and %s", first, again)
+	}
+	write("notes.txt", "ignored\n")
+	if ignored := digest(); ignored != first {
+		t.Errorf("expected files of .helmignore not to change the digest")
+	}
+	write("values.yaml", "replicas: 2\n")
+	if changed := digest(); changed == first {
+		t.Errorf("expected a changed values.yaml to change the digest")
+	}
+}
+
+func TestLockChartVersionResolvesRelease(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lincos-lock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"api", "web"} {
+		chartDir := filepath.Join(dir, name)
+		if err := os.MkdirAll(chartDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		metadata := "apiVersion: v2\nname: " + name + "\nversion: 1.2.0\n"
+		if err := ioutil.WriteFile(filepath.Join(chartDir, "Chart.yaml"), []byte(metadata), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// api is locked already and not given as an argument
+	o := &lockOptions{lockFile: filepath.Join(dir, defaultLockFile)}
+	lock := &lockFile{Releases: map[string]*lockEntry{
+		"api": {Chart: filepath.Join(dir, "api"), Version: "1.1.0"},
+	}}
+	if err := saveLockFile(o.lockFile, lock); err != nil {
+		t.Fatal(err)
+	}
+
+	o.chartVersions = []string{"api=^1.2"}
+	changes, err := RunLock([]string{"web=" + filepath.Join(dir, "web")}, o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolved := []string{}
+	for _, c := range changes {
+		resolved = append(resolved, c.Release)
+	}
+	if !reflect.DeepEqual(resolved, []string{"api", "web"}) {
+		t.Fatalf("expected api and web to be resolved, got %v", resolved)
+	}
+	if c := changes[0]; c.Constraint != "^1.2" || c.Version != "1.2.0" || c.Previous != "1.1.0" {
+		t.Errorf("expected api to be resolved again within ^1.2, got %+v", *c.lockEntry)
+	}
+
+	saved, err := loadLockFile(o.lockFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := saved.Releases["api"]; e.Constraint != "^1.2" || e.Version != "1.2.0" {
+		t.Errorf("expected the lock file to record api 1.2.0 within ^1.2, got %+v", *e)
+	}
+}
@@ -0,0 +1,515 @@
+/*
+Copyright © 2020 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/gosuri/uitable"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+
+	"helm.sh/helm/v3/cmd/helm/require"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/cli/output"
+	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage/driver"
+)
+
+const (
+	defaultPlanFile  = "plan.lincos"
+	planManifestFile = "plan.yaml"
+)
+
+const planDesc = `
+Prepare the deploy of the releases of a lincos.yaml file and save it as a plan.
+
+For every release the chart is resolved and stored in the plan together with
+its merged values. The manifests are rendered, compared with the deployed
+release and stored in the plan for review, and the deployed revision of the
+release is recorded. Use '--environment' and '--selector' like with
+'lincos apply'.
+
+    $ lincos plan --out plan.lincos
+    $ lincos apply plan.lincos
+
+Applying a plan deploys exactly the charts and values of the plan. It fails
+before deploying anything when a release was changed since the plan was made,
+or when a release renders other manifests than stored in the plan, e.g.
+because its chart uses 'lookup', random values or the cluster capabilities.
+`
+
+// planFile describes the releases of a plan, the charts, values and
+// manifests are stored next to it
+type planFile struct {
+	Project     string            `json:"project"`
+	Environment string            `json:"environment,omitempty"`
+	Releases    []*plannedRelease `json:"releases"`
+}
+
+// plannedRelease is a release resolved against the cluster at plan time
+type plannedRelease struct {
+	Name        string        `json:"name"`
+	Namespace   string        `json:"namespace"`
+	KubeContext string        `json:"kubeContext"`
+	Chart       string        `json:"chart"`
+	ChartFile   string        `json:"chartFile"`
+	Version     string        `json:"version"`
+	Values      string        `json:"values"`
+	Manifest    string        `json:"manifest"`
+	Revision    int           `json:"revision"`
+	Needs       []string      `json:"needs,omitempty"`
+	Options     deployOptions `json:"options"`
+}
+
+type planOptions struct {
+	lincosFileOptions
+	out     string
+	noColor bool
+}
+
+func newPlanCmd(out io.Writer) *cobra.Command {
+	o := &planOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "plan",
+		Short: "Save the deploy of the releases of a lincos.yaml file as a plan",
+		Long:  planDesc,
+		Args:  require.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			plan, err := RunPlan(o, out)
+			if err != nil {
+				return err
+			}
+			if err := output.EncodeTable(out, planTable(plan)); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "\nPlan saved to %s, deploy it with 'lincos apply %s'\n", o.out, o.out)
+			return nil
+		},
+	}
+
+	f := cmd.Flags()
+	addLincosFileFlags(f, &o.lincosFileOptions)
+	f.StringVar(&o.out, "out", defaultPlanFile, "path to write the plan to")
+	f.BoolVar(&o.noColor, "no-color", false, "disable colored output")
+	flags := cmd.PersistentFlags()
+	settings.AddFlags(flags)
+
+	return cmd
+}
+
+//Resolve, render and save the releases of a lincos.yaml as a plan
+func RunPlan(o *planOptions, out io.Writer) (*planFile, error) {
+
+	setLogger()
+	if o.noColor {
+		color.NoColor = true
+	}
+	releases, cleanup, err := o.releases()
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	dir, err := ioutil.TempDir("", "lincos-plan")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	plan := &planFile{Environment: o.environment}
+	for _, r := range releases {
+		plan.Project = r.project
+		p, err := planRelease(r, dir, out)
+		if err != nil {
+			return nil, errors.Wrapf(err, "release %s", r.Name)
+		}
+		plan.Releases = append(plan.Releases, p)
+	}
+
+	data, err := yaml.Marshal(plan)
+	if err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, planManifestFile), data, 0644); err != nil {
+		return nil, err
+	}
+	return plan, writePlan(dir, o.out)
+}
+
+// planRelease stores the chart and merged values of a release in dir and
+// renders them the same way applying the plan will deploy them
+func planRelease(r *releaseSpec, dir string, out io.Writer) (*plannedRelease, error) {
+	cfg, namespace, err := r.actionConfig()
+	if err != nil {
+		return nil, err
+	}
+	current, err := currentRelease(cfg, r.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &plannedRelease{
+		Name:        r.Name,
+		Namespace:   namespace,
+		KubeContext: currentKubeContext(r.KubeContext),
+		Chart:       r.Chart,
+		Needs:       r.Needs,
+		Options:     r.deployOptions,
+	}
+	// The plan holds the chart with its dependencies
+	p.Options.DependencyUpdate = false
+	if current != nil {
+		p.Revision = current.Version
+	}
+
+	_, clientUpgrade := r.clients(cfg)
+	cpo := &clientUpgrade.ChartPathOptions
+	cp, _, _, err := locateReleaseChart(cpo, r.Chart, namespace)
+	if err != nil {
+		return nil, err
+	}
+	// Progress of a dependency update must not end up in the plan output
+	ch, err := loadChart(cp, cpo, r.DependencyUpdate, os.Stderr)
+	if err != nil {
+		return nil, err
+	}
+	p.Version = ch.Metadata.Version
+
+	tmp, err := ioutil.TempDir("", "lincos-plan-chart")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+	archive, err := bundleArchive(ch, cp, tmp)
+	if err != nil {
+		return nil, err
+	}
+	p.ChartFile = path.Join("charts", r.Name, filepath.Base(archive))
+	if err := copyPlanFile(archive, dir, p.ChartFile); err != nil {
+		return nil, err
+	}
+	// Keep the provenance file so that the chart can still be verified
+	if _, err := os.Stat(archive + ".prov"); err == nil {
+		if err := copyPlanFile(archive+".prov", dir, p.ChartFile+".prov"); err != nil {
+			return nil, err
+		}
+	}
+
+	vals, err := r.valueOptions().MergeValues(getter.All(settings))
+	if err != nil {
+		return nil, err
+	}
+	data, err := yaml.Marshal(vals)
+	if err != nil {
+		return nil, err
+	}
+	p.Values = path.Join("values", r.Name+".yaml")
+	if err := writePlanFile(dir, p.Values, data); err != nil {
+		return nil, err
+	}
+
+	rel, manifest, err := renderPlannedRelease(p.releaseSpec(dir, r.project))
+	if err != nil {
+		return nil, err
+	}
+	p.Manifest = path.Join("manifests", r.Name+".yaml")
+	if err := writePlanFile(dir, p.Manifest, []byte(manifest)); err != nil {
+		return nil, err
+	}
+
+	color.New(color.Bold).Fprintf(out, "Release \"%s\" in namespace \"%s\":\n", r.Name, namespace)
+	if _, err := diffReleases(out, current, rel, 3); err != nil {
+		return nil, err
+	}
+	fmt.Fprintln(out)
+	return p, nil
+}
+
+// renderPlannedRelease renders a planned release with a dry-run deploy and
+// returns it with its manifests and hooks
+func renderPlannedRelease(spec *releaseSpec) (*release.Release, string, error) {
+	cfg := new(action.Configuration)
+	client, clientUpgrade := spec.clients(cfg)
+	client.DryRun = true
+	clientUpgrade.DryRun = true
+	rel, _, err := RunDeploy([]string{spec.Name, spec.Chart}, cfg, client, clientUpgrade, spec.valueOptions(), spec.KubeContext, os.Stderr)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var manifest strings.Builder
+	fmt.Fprintln(&manifest, strings.TrimSpace(rel.Manifest))
+	for _, h := range rel.Hooks {
+		fmt.Fprintf(&manifest, "---\n# Source: %s\n%s\n", h.Path, h.Manifest)
+	}
+	return rel, manifest.String(), nil
+}
+
+// checkPlanManifests renders every release of the plan again and fails when
+// it no longer matches the manifests which were reviewed, for example because
+// the chart uses lookup, random values or the capabilities of the cluster
+func checkPlanManifests(plan *planFile, dir string) error {
+	var changed []string
+	for _, p := range plan.Releases {
+		planned, err := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(p.Manifest)))
+		if err != nil {
+			return err
+		}
+		_, manifest, err := renderPlannedRelease(p.releaseSpec(dir, plan.Project))
+		if err != nil {
+			return errors.Wrapf(err, "release %s", p.Name)
+		}
+		if manifest != string(planned) {
+			debug("Release \"%s\" renders differently than planned", p.Name)
+			changed = append(changed, p.Name)
+		}
+	}
+	if len(changed) > 0 {
+		return errors.Errorf("releases render other manifests than planned, create a new plan: %s", strings.Join(changed, ", "))
+	}
+	return nil
+}
+
+// releaseSpec deploys the planned release from the plan extracted to dir
+func (p *plannedRelease) releaseSpec(dir, project string) *releaseSpec {
+	return &releaseSpec{
+		Name:          p.Name,
+		Chart:         filepath.Join(dir, filepath.FromSlash(p.ChartFile)),
+		Namespace:     p.Namespace,
+		KubeContext:   p.KubeContext,
+		Values:        []string{filepath.Join(dir, filepath.FromSlash(p.Values))},
+		Needs:         p.Needs,
+		deployOptions: p.Options,
+		project:       project,
+	}
+}
+
+// actionConfig returns the configuration of the kube context and namespace
+// the release was planned for
+func (p *plannedRelease) actionConfig() (*action.Configuration, error) {
+	spec := &releaseSpec{Namespace: p.Namespace, KubeContext: p.KubeContext}
+	cfg, _, err := spec.actionConfig()
+	return cfg, err
+}
+
+// currentRelease returns the latest revision of a release, nil when the
+// release is not installed
+func currentRelease(cfg *action.Configuration, name string) (*release.Release, error) {
+	status, err := NewStatus(cfg, name)
+	if err != nil {
+		return nil, err
+	}
+	rel, err := status.InfoStatus()
+	if errors.Cause(err) == driver.ErrReleaseNotFound {
+		return nil, nil
+	}
+	return rel, err
+}
+
+// currentKubeContext returns the name of the kube context a release is
+// deployed to, so that a plan doesn't depend on the current context
+func currentKubeContext(kubeContext string) string {
+	if kubeContext != "" {
+		return kubeContext
+	}
+	if settings.KubeContext != "" {
+		return settings.KubeContext
+	}
+	kubeCfg, _ := kubeConfig("", "")
+	raw, err := kubeCfg.ToRawKubeConfigLoader().RawConfig()
+	if err != nil {
+		return ""
+	}
+	return raw.CurrentContext
+}
+
+func copyPlanFile(src, dir, name string) error {
+	data, err := ioutil.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return writePlanFile(dir, name, data)
+}
+
+func writePlanFile(dir, name string, data []byte) error {
+	file := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, data, 0644)
+}
+
+// writePlan packs dir into the plan file, with checksums like a bundle
+func writePlan(dir, file string) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(file), ".plan-*.lincos")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	zw := gzip.NewWriter(tmp)
+	w := &bundleWriter{tw: tar.NewWriter(zw), sums: map[string]string{}}
+	err = filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		data, err := ioutil.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		return w.Add(filepath.ToSlash(rel), data)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := w.Close(); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	// Temporary files are only readable by their owner
+	if err := tmp.Chmod(0644); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
+
+// openPlan extracts a plan into dir and verifies its checksums
+func openPlan(file, dir string) (*planFile, error) {
+	if err := extractBundle(file, dir); err != nil {
+		return nil, err
+	}
+	if err := verifyBundle(dir); err != nil {
+		return nil, errors.Wrapf(err, "plan %s", file)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(dir, planManifestFile))
+	if err != nil {
+		return nil, err
+	}
+	plan := &planFile{}
+	if err := yaml.UnmarshalStrict(data, plan); err != nil {
+		return nil, errors.Wrapf(err, "invalid %s in plan %s", planManifestFile, file)
+	}
+	return plan, nil
+}
+
+// checkPlanRevisions fails when any release has another revision than when
+// the plan was made. actionConfig returns the configuration of the kube
+// context and namespace of a release.
+func checkPlanRevisions(plan *planFile, actionConfig func(p *plannedRelease) (*action.Configuration, error)) error {
+	var changed []string
+	for _, p := range plan.Releases {
+		cfg, err := actionConfig(p)
+		if err != nil {
+			return err
+		}
+		current, err := currentRelease(cfg, p.Name)
+		if err != nil {
+			return errors.Wrapf(err, "release %s", p.Name)
+		}
+		revision := 0
+		if current != nil {
+			revision = current.Version
+		}
+		if revision != p.Revision {
+			changed = append(changed, fmt.Sprintf("%s is at revision %d, planned at revision %d", p.Name, revision, p.Revision))
+		}
+	}
+	if len(changed) > 0 {
+		return errors.Errorf("releases changed since the plan was made, create a new plan: %s", strings.Join(changed, "; "))
+	}
+	return nil
+}
+
+//Deploy the releases of a saved plan
+func RunApplyPlan(file string, o *applyOptions, errOut io.Writer) ([]applyResult, error) {
+
+	setLogger()
+	if o.concurrency < 1 {
+		return nil, errors.New("--concurrency must be at least 1")
+	}
+	dir, err := ioutil.TempDir("", "lincos-plan")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	plan, err := openPlan(file, dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkPlanRevisions(plan, (*plannedRelease).actionConfig); err != nil {
+		return nil, err
+	}
+	if _, err := loadConfig(); err != nil {
+		return nil, err
+	}
+	if err := checkPlanManifests(plan, dir); err != nil {
+		return nil, err
+	}
+
+	releases := make([]*releaseSpec, 0, len(plan.Releases))
+	charts := map[string]string{}
+	for _, p := range plan.Releases {
+		releases = append(releases, p.releaseSpec(dir, plan.Project))
+		charts[p.Name] = p.Chart
+	}
+	results := applyReleases(releases, o.concurrency, func(r *releaseSpec) applyResult {
+		return applyRelease(r, o.dryRun, errOut)
+	})
+	// Report the charts as declared, not the extracted archives
+	for i := range results {
+		results[i].Chart = charts[results[i].Release]
+	}
+	return results, nil
+}
+
+func planTable(plan *planFile) *uitable.Table {
+	table := uitable.New()
+	table.AddRow("RELEASE", "NAMESPACE", "KUBE CONTEXT", "CHART", "VERSION", "REVISION", "ACTION")
+	for _, p := range plan.Releases {
+		revision, act := fmt.Sprint(p.Revision), "upgrade"
+		if p.Revision == 0 {
+			revision, act = "", "install"
+		}
+		table.AddRow(p.Name, p.Namespace, p.KubeContext, p.Chart, p.Version, revision, act)
+	}
+	return table
+}
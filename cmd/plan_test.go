@@ -0,0 +1,171 @@
+/*
+Copyright © 2020 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chartutil"
+	kubefake "helm.sh/helm/v3/pkg/kube/fake"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage"
+	"helm.sh/helm/v3/pkg/storage/driver"
+)
+
+// writeTestPlan packs a plan of one release into a plan file in dir
+func writeTestPlan(t *testing.T, dir string) (string, *planFile) {
+	plan := &planFile{
+		Project: "shop",
+		Releases: []*plannedRelease{{
+			Name:      "api",
+			Namespace: "shop",
+			ChartFile: "charts/api-1.0.0.tgz",
+			Values:    "values/api.yaml",
+			Manifest:  "manifests/api.yaml",
+			Revision:  2,
+		}},
+	}
+	data, err := yaml.Marshal(plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src := filepath.Join(dir, "src")
+	files := map[string][]byte{
+		planManifestFile:       data,
+		"charts/api-1.0.0.tgz": []byte("chart"),
+		"values/api.yaml":      []byte("replicas: 2\n"),
+		"manifests/api.yaml":   []byte("kind: ConfigMap\n"),
+	}
+	for name, data := range files {
+		if err := writePlanFile(src, name, data); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	file := filepath.Join(dir, "plan.lincos")
+	if err := writePlan(src, file); err != nil {
+		t.Fatal(err)
+	}
+	return file, plan
+}
+
+func TestWritePlanRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lincos-plan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file, expected := writeTestPlan(t, dir)
+	info, err := os.Stat(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0644 {
+		t.Errorf("expected the plan file to have mode 0644, got %o", mode)
+	}
+
+	out := filepath.Join(dir, "out")
+	plan, err := openPlan(file, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(plan, expected) {
+		t.Errorf("expected plan %+v, got %+v", expected, plan)
+	}
+	values, err := ioutil.ReadFile(filepath.Join(out, "values", "api.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(values) != "replicas: 2\n" {
+		t.Errorf("expected the planned values, got %q", values)
+	}
+}
+
+func TestOpenPlanTampered(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lincos-plan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file, _ := writeTestPlan(t, dir)
+	out := filepath.Join(dir, "out")
+	if err := extractBundle(file, out); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(out, "values", "api.yaml"), []byte("replicas: 20\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err = verifyBundle(out)
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch for values/api.yaml") {
+		t.Errorf("expected a checksum mismatch for the changed values, got %v", err)
+	}
+}
+
+func TestCheckPlanRevisions(t *testing.T) {
+	cfg := &action.Configuration{
+		Releases:     storage.Init(driver.NewMemory()),
+		KubeClient:   &kubefake.PrintingKubeClient{Out: ioutil.Discard},
+		Capabilities: chartutil.DefaultCapabilities,
+		Log:          t.Logf,
+	}
+	for _, version := range []int{1, 2} {
+		rel := &release.Release{
+			Name:      "api",
+			Namespace: "shop",
+			Version:   version,
+			Info:      &release.Info{Status: release.StatusDeployed},
+		}
+		if err := cfg.Releases.Create(rel); err != nil {
+			t.Fatal(err)
+		}
+	}
+	actionConfig := func(p *plannedRelease) (*action.Configuration, error) {
+		return cfg, nil
+	}
+
+	tests := []struct {
+		name     string
+		revision int
+		err      string
+	}{
+		{"api", 2, ""},
+		{"api", 1, "api is at revision 2, planned at revision 1"},
+		{"web", 0, ""},
+		{"web", 1, "web is at revision 0, planned at revision 1"},
+	}
+	for _, tt := range tests {
+		plan := &planFile{Releases: []*plannedRelease{{Name: tt.name, Namespace: "shop", Revision: tt.revision}}}
+		err := checkPlanRevisions(plan, actionConfig)
+		switch {
+		case tt.err == "" && err != nil:
+			t.Errorf("%s at revision %d: unexpected error %v", tt.name, tt.revision, err)
+		case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+			t.Errorf("%s at revision %d: expected error %q, got %v", tt.name, tt.revision, tt.err, err)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+/*
+Copyright © 2020 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/downloader"
+)
+
+// defaultKeyring returns the expanded path to the default keyring
+func defaultKeyring() string {
+	if v, ok := os.LookupEnv("GNUPGHOME"); ok {
+		return filepath.Join(v, "pubring.gpg")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".gnupg", "pubring.gpg")
+}
+
+// applyProvenancePolicy turns on verification when the configured policy
+// requires verified charts in namespace. It returns whether it did. A keyring
+// which wasn't given with --keyring is the keyring of the policy, if any, or
+// the default one.
+func applyProvenancePolicy(c *action.ChartPathOptions, namespace string) (bool, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return false, err
+	}
+	policy := cfg.Provenance
+	required := policy.RequiresVerification(namespace)
+	if required {
+		c.Verify = true
+		if c.Keyring == "" {
+			c.Keyring = policy.Keyring
+		}
+	}
+	if c.Keyring == "" {
+		c.Keyring = defaultKeyring()
+	}
+	return required, nil
+}
+
+// clearDefaultKeyring empties the keyring when --keyring wasn't given, so
+// that applyProvenancePolicy picks it
+func clearDefaultKeyring(cmd *cobra.Command, c *action.ChartPathOptions) {
+	if !cmd.Flags().Changed("keyring") {
+		c.Keyring = ""
+	}
+}
+
+// checkVerifiable fails early for charts which can't be verified: charts of
+// OCI registries come without a provenance file
+func checkVerifiable(chart, namespace string, required bool) error {
+	if !strings.HasPrefix(chart, ociScheme) {
+		return nil
+	}
+	if required {
+		return errors.Errorf("namespace %q only accepts verified charts, %s can't be verified: charts of OCI registries have no provenance file", namespace, chart)
+	}
+	return errors.Errorf("%s can't be verified: charts of OCI registries have no provenance file, deploy it without --verify", chart)
+}
+
+// provenanceReport is the verification of the chart of a release
+type provenanceReport struct {
+	Chart            string   `json:"chart"`
+	File             string   `json:"file"`
+	Hash             string   `json:"hash"`
+	SignedBy         []string `json:"signedBy"`
+	Fingerprint      string   `json:"fingerprint"`
+	Keyring          string   `json:"keyring"`
+	RequiredByPolicy bool     `json:"requiredByPolicy"`
+}
+
+// verifyChart checks the provenance file of a located chart and returns the
+// verification report
+func verifyChart(c *action.ChartPathOptions, chart, cp, namespace string, required bool) (*provenanceReport, error) {
+	v, err := downloader.VerifyChart(cp, c.Keyring)
+	if err != nil {
+		if required {
+			return nil, errors.Wrapf(err, "namespace %q only accepts verified charts, %s failed verification", namespace, chart)
+		}
+		return nil, errors.Wrapf(err, "%s failed verification", chart)
+	}
+
+	report := &provenanceReport{
+		Chart:            chart,
+		File:             v.FileName,
+		Hash:             v.FileHash,
+		Fingerprint:      fmt.Sprintf("%X", v.SignedBy.PrimaryKey.Fingerprint),
+		Keyring:          c.Keyring,
+		RequiredByPolicy: required,
+	}
+	for name := range v.SignedBy.Identities {
+		report.SignedBy = append(report.SignedBy, name)
+	}
+	sort.Strings(report.SignedBy)
+
+	log.WithTime(time.Now()).WithFields(log.Fields{
+		"chart":          chart,
+		"hash":           report.Hash,
+		"signedBy":       strings.Join(report.SignedBy, ", "),
+		"Namespace":      namespace,
+		"requiredPolicy": required,
+	}).Debug("Chart provenance verified.")
+
+	return report, nil
+}
+
+// writeTable prints the report as the PROVENANCE section of the deploy output
+func (p *provenanceReport) writeTable(out io.Writer) {
+	fmt.Fprintln(out, "PROVENANCE:")
+	fmt.Fprintf(out, "  Chart:              %s\n", p.Chart)
+	fmt.Fprintf(out, "  File:               %s\n", p.File)
+	fmt.Fprintf(out, "  Hash:               %s\n", p.Hash)
+	fmt.Fprintf(out, "  Signed by:          %s\n", strings.Join(p.SignedBy, ", "))
+	fmt.Fprintf(out, "  Fingerprint:        %s\n", p.Fingerprint)
+	fmt.Fprintf(out, "  Keyring:            %s\n", p.Keyring)
+	fmt.Fprintf(out, "  Required by policy: %t\n", p.RequiredByPolicy)
+}
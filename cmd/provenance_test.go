@@ -0,0 +1,214 @@
+/*
+Copyright © 2020 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/openpgp"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/provenance"
+)
+
+func TestRequiresVerification(t *testing.T) {
+	policy := &provenancePolicy{RequireVerified: []string{"prod", "payments-*"}}
+	tests := map[string]bool{
+		"prod":                true,
+		"prod-eu":             false,
+		"payments-":           true,
+		"payments-eu":         true,
+		"payments":            false,
+		"staging":             false,
+		"default":             false,
+		"billing-payments-eu": false,
+	}
+	for namespace, expected := range tests {
+		if required := policy.RequiresVerification(namespace); required != expected {
+			t.Errorf("expected verification of %q to be required %t, got %t", namespace, expected, required)
+		}
+	}
+}
+
+func TestApplyProvenancePolicyKeyring(t *testing.T) {
+	defer func(c *lincosConfig) { config = c }(config)
+
+	tests := []struct {
+		desc          string
+		policyKeyring string
+		namespace     string
+		keyring       string
+		verify        bool
+		expected      string
+	}{
+		{"policy keyring for required namespaces", "/etc/lincos/pubring.gpg", "prod", "", true, "/etc/lincos/pubring.gpg"},
+		{"default keyring elsewhere", "/etc/lincos/pubring.gpg", "staging", "", false, defaultKeyring()},
+		{"default keyring without policy keyring", "", "prod", "", true, defaultKeyring()},
+		{"--keyring wins over the policy", "/etc/lincos/pubring.gpg", "prod", "/tmp/pubring.gpg", true, "/tmp/pubring.gpg"},
+		{"--keyring with the default path wins", "/etc/lincos/pubring.gpg", "prod", defaultKeyring(), true, defaultKeyring()},
+	}
+	for _, tt := range tests {
+		config = &lincosConfig{Provenance: provenancePolicy{
+			Keyring:         tt.policyKeyring,
+			RequireVerified: []string{"prod"},
+		}}
+		c := &action.ChartPathOptions{Keyring: tt.keyring}
+		required, err := applyProvenancePolicy(c, tt.namespace)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if required != tt.verify || c.Verify != tt.verify {
+			t.Errorf("%s: expected verification %t, got required %t and verify %t", tt.desc, tt.verify, required, c.Verify)
+		}
+		if c.Keyring != tt.expected {
+			t.Errorf("%s: expected keyring %s, got %s", tt.desc, tt.expected, c.Keyring)
+		}
+	}
+}
+
+func TestClearDefaultKeyring(t *testing.T) {
+	tests := map[string][]string{
+		"":               {},
+		defaultKeyring(): {"--keyring", defaultKeyring()},
+		"/tmp/ring.gpg":  {"--keyring", "/tmp/ring.gpg"},
+	}
+	for expected, args := range tests {
+		c := &action.ChartPathOptions{}
+		cmd := &cobra.Command{}
+		addChartPathOptionsFlags(cmd.Flags(), c)
+		if err := cmd.ParseFlags(args); err != nil {
+			t.Fatal(err)
+		}
+		clearDefaultKeyring(cmd, c)
+		if c.Keyring != expected {
+			t.Errorf("expected keyring %q for %v, got %q", expected, args, c.Keyring)
+		}
+	}
+}
+
+// signedTestChart packages a chart signed by a new key into dir, it returns
+// the archive, the keyring holding the public key and the signing key
+func signedTestChart(t *testing.T, dir string) (string, string, *openpgp.Entity) {
+	chartDir := filepath.Join(dir, "api")
+	if err := os.MkdirAll(chartDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(chartDir, "Chart.yaml"), []byte("apiVersion: v2\nname: api\nversion: 1.0.0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ch, err := loader.Load(chartDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive, err := chartutil.Save(ch, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := openpgp.NewEntity("Release Team", "", "release@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := &provenance.Signatory{Entity: key, KeyRing: openpgp.EntityList{key}}
+	sig, err := signer.ClearSign(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(archive+".prov", []byte(sig), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var pubring bytes.Buffer
+	if err := key.Serialize(&pubring); err != nil {
+		t.Fatal(err)
+	}
+	keyring := filepath.Join(dir, "pubring.gpg")
+	if err := ioutil.WriteFile(keyring, pubring.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return archive, keyring, key
+}
+
+func TestVerifyChartReport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lincos-provenance")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	archive, keyring, key := signedTestChart(t, dir)
+	c := &action.ChartPathOptions{Verify: true, Keyring: keyring}
+	report, err := verifyChart(c, "shop/api", archive, "prod", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hash, err := provenance.DigestFile(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &provenanceReport{
+		Chart:            "shop/api",
+		File:             filepath.Base(archive),
+		Hash:             "sha256:" + hash,
+		SignedBy:         []string{"Release Team <release@example.com>"},
+		Fingerprint:      fmt.Sprintf("%X", key.PrimaryKey.Fingerprint),
+		Keyring:          keyring,
+		RequiredByPolicy: true,
+	}
+	if fmt.Sprintf("%+v", report) != fmt.Sprintf("%+v", expected) {
+		t.Errorf("expected report %+v, got %+v", expected, report)
+	}
+
+	var out bytes.Buffer
+	report.writeTable(&out)
+	for _, line := range []string{
+		"PROVENANCE:",
+		"  Chart:              shop/api",
+		"  Signed by:          Release Team <release@example.com>",
+		"  Required by policy: true",
+	} {
+		if !strings.Contains(out.String(), line+"\n") {
+			t.Errorf("expected the table to contain %q, got\n%s", line, out.String())
+		}
+	}
+}
+
+func TestVerifyChartFailsWithOtherKey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lincos-provenance")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	archive, _, _ := signedTestChart(t, dir)
+	_, otherKeyring, _ := signedTestChart(t, filepath.Join(dir, "other"))
+	c := &action.ChartPathOptions{Verify: true, Keyring: otherKeyring}
+	_, err = verifyChart(c, "shop/api", archive, "prod", true)
+	if err == nil || !strings.Contains(err.Error(), `namespace "prod" only accepts verified charts`) {
+		t.Errorf("expected the verification required by the policy to fail, got %v", err)
+	}
+}
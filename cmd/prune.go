@@ -0,0 +1,267 @@
+/*
+Copyright © 2020 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/gosuri/uitable"
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"helm.sh/helm/v3/pkg/action"
+)
+
+const (
+	applyStatusPruned = "pruned"
+
+	// projectConfigMapPrefix starts the name of the ConfigMap which tracks the
+	// releases of a project in a namespace
+	projectConfigMapPrefix = "lincos-project-"
+	projectAnnotation      = "lincos/project"
+)
+
+var managedPattern = regexp.MustCompile(`\(managed by lincos project ([^()]+)\)`)
+
+// managedDescription marks the release description as managed by a project
+func managedDescription(description, defaultDescription, project string) string {
+	if description == "" {
+		description = defaultDescription
+	}
+	return fmt.Sprintf("%s (managed by lincos project %s)", description, project)
+}
+
+// managedProject returns the project managing a release, empty when the
+// release is not managed by lincos
+func managedProject(description string) string {
+	if m := managedPattern.FindStringSubmatch(description); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// projectConfigMap returns the name of the ConfigMap tracking the releases of
+// a project, projects may contain characters a name can't
+func projectConfigMap(project string) string {
+	return fmt.Sprintf("%s%x", projectConfigMapPrefix, sha256.Sum256([]byte(project)))[:len(projectConfigMapPrefix)+16]
+}
+
+// projectConfigMaps returns the ConfigMaps of a namespace
+func projectConfigMaps(cfg *action.Configuration, namespace string) (corev1.ConfigMapInterface, error) {
+	client, err := cfg.KubernetesClientSet()
+	if err != nil {
+		return nil, err
+	}
+	return client.CoreV1().ConfigMaps(namespace), nil
+}
+
+// trackRelease records a release in the ConfigMap of its project. Unlike the
+// description, the ConfigMap keeps the release managed when a later revision
+// is a failed upgrade or a rollback.
+func trackRelease(configMaps corev1.ConfigMapInterface, project, name, chart string) error {
+	patch, err := json.Marshal(map[string]interface{}{"data": map[string]string{name: chart}})
+	if err != nil {
+		return err
+	}
+
+	// A merge patch adds the release without conflicting with releases of the
+	// same project deployed at the same time
+	_, err = configMaps.Patch(context.Background(), projectConfigMap(project), types.MergePatchType, patch, metav1.PatchOptions{})
+	if !apierrors.IsNotFound(err) {
+		return err
+	}
+	_, err = configMaps.Create(context.Background(), &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        projectConfigMap(project),
+			Labels:      map[string]string{"app.kubernetes.io/managed-by": "lincos"},
+			Annotations: map[string]string{projectAnnotation: project},
+		},
+		Data: map[string]string{name: chart},
+	}, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		_, err = configMaps.Patch(context.Background(), projectConfigMap(project), types.MergePatchType, patch, metav1.PatchOptions{})
+	}
+	return err
+}
+
+// untrackRelease removes an uninstalled release from the ConfigMap of its project
+func untrackRelease(configMaps corev1.ConfigMapInterface, project, name string) error {
+	patch, err := json.Marshal(map[string]interface{}{"data": map[string]interface{}{name: nil}})
+	if err != nil {
+		return err
+	}
+	_, err = configMaps.Patch(context.Background(), projectConfigMap(project), types.MergePatchType, patch, metav1.PatchOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// trackedReleases returns the releases the ConfigMap of a project tracks in a namespace
+func trackedReleases(configMaps corev1.ConfigMapInterface, project string) (map[string]bool, error) {
+	cm, err := configMaps.Get(context.Background(), projectConfigMap(project), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return map[string]bool{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	tracked := map[string]bool{}
+	if cm.Annotations[projectAnnotation] != project {
+		return tracked, nil
+	}
+	for name := range cm.Data {
+		tracked[name] = true
+	}
+	return tracked, nil
+}
+
+// releaseScope is a kube context and namespace releases are deployed to
+type releaseScope struct {
+	kubeContext string
+	namespace   string
+}
+
+func (r *releaseSpec) scope() releaseScope {
+	_, namespace := kubeConfig(r.KubeContext, r.Namespace)
+	return releaseScope{currentKubeContext(r.KubeContext), namespace}
+}
+
+// planPrune finds the releases managed by the project which are no longer
+// declared. Only the kube contexts and namespaces of the selected releases
+// are searched, the declared releases are all releases of the environment.
+func planPrune(o *lincosFileOptions, selected []*releaseSpec) ([]*releaseSpec, error) {
+	lf, err := loadLincosFile(o.file)
+	if err != nil {
+		return nil, err
+	}
+	if lf.defaultProject {
+		return nil, errors.Errorf("%s: --prune needs the 'project' of the releases, set it in the file", o.file)
+	}
+	declared, err := lf.releasesFor(o.environment)
+	if err != nil {
+		return nil, err
+	}
+	names := map[releaseScope]map[string]bool{}
+	for _, r := range declared {
+		s := r.scope()
+		if names[s] == nil {
+			names[s] = map[string]bool{}
+		}
+		names[s][r.Name] = true
+	}
+
+	scopes := map[releaseScope]bool{}
+	for _, r := range selected {
+		scopes[r.scope()] = true
+	}
+
+	prune := []*releaseSpec{}
+	for s := range scopes {
+		scopeSpec := &releaseSpec{Namespace: s.namespace, KubeContext: s.kubeContext}
+		cfg, _, err := scopeSpec.actionConfig()
+		if err != nil {
+			return nil, err
+		}
+		client := action.NewList(cfg)
+		client.All = true
+		client.StateMask = action.ListDeployed | action.ListFailed | action.ListPendingInstall | action.ListPendingUpgrade | action.ListPendingRollback
+		releases, err := client.Run()
+		if err != nil {
+			return nil, err
+		}
+		configMaps, err := projectConfigMaps(cfg, s.namespace)
+		if err != nil {
+			return nil, err
+		}
+		tracked, err := trackedReleases(configMaps, lf.Project)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, rel := range releases {
+			// The description names the project which deployed the latest
+			// revision, the ConfigMap covers revisions lincos didn't deploy
+			project := managedProject(rel.Info.Description)
+			if project == "" && tracked[rel.Name] {
+				project = lf.Project
+			}
+			if project != lf.Project || names[s][rel.Name] {
+				continue
+			}
+			debug("Release \"%s\" in namespace \"%s\" is managed by \"%s\" but not declared", rel.Name, rel.Namespace, lf.Project)
+			spec := &releaseSpec{
+				Name:        rel.Name,
+				Namespace:   rel.Namespace,
+				KubeContext: s.kubeContext,
+				project:     lf.Project,
+			}
+			// A corrupted release record may miss its chart
+			if rel.Chart != nil && rel.Chart.Metadata != nil {
+				spec.Chart = rel.Chart.Metadata.Name
+				spec.Version = rel.Chart.Metadata.Version
+			}
+			prune = append(prune, spec)
+		}
+	}
+	sort.Slice(prune, func(i, j int) bool {
+		if prune[i].Namespace != prune[j].Namespace {
+			return prune[i].Namespace < prune[j].Namespace
+		}
+		return prune[i].Name < prune[j].Name
+	})
+	return prune, nil
+}
+
+// pruneReleases uninstalls the releases of the prune plan
+func pruneReleases(prune []*releaseSpec, dryRun bool) []applyResult {
+	opts := &action.Uninstall{
+		DryRun:  dryRun,
+		Timeout: 300 * time.Second,
+	}
+	results := make([]applyResult, 0, len(prune))
+	for _, r := range prune {
+		result := destroyRelease(r, opts)
+		if result.Error == "" && !dryRun {
+			result.Status = applyStatusPruned
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+func writePrunePlan(out io.Writer, prune []*releaseSpec) {
+	table := uitable.New()
+	table.AddRow("RELEASE", "NAMESPACE", "KUBE CONTEXT", "CHART", "VERSION")
+	for _, r := range prune {
+		table.AddRow(r.Name, r.Namespace, r.KubeContext, r.Chart, r.Version)
+	}
+	fmt.Fprintln(out, "These releases are no longer declared and will be uninstalled:")
+	fmt.Fprintln(out, table)
+	fmt.Fprintln(out)
+}
@@ -0,0 +1,72 @@
+/*
+Copyright © 2020 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestManagedProject(t *testing.T) {
+	tests := map[string]string{
+		managedDescription("", "Install complete", "shop"):            "shop",
+		managedDescription("Release 1.2", "Upgrade complete", "shop"): "shop",
+		"Upgrade \"api\" failed: timed out waiting for the condition": "",
+		"Rollback to 3": "",
+	}
+	for description, expected := range tests {
+		if project := managedProject(description); project != expected {
+			t.Errorf("expected project %q for %q, got %q", expected, description, project)
+		}
+	}
+}
+
+func TestTrackRelease(t *testing.T) {
+	configMaps := fake.NewSimpleClientset().CoreV1().ConfigMaps("web")
+
+	for _, name := range []string{"api", "web"} {
+		if err := trackRelease(configMaps, "shop", name, "stable/"+name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := trackRelease(configMaps, "blog", "blog", "stable/blog"); err != nil {
+		t.Fatal(err)
+	}
+	if err := untrackRelease(configMaps, "shop", "web"); err != nil {
+		t.Fatal(err)
+	}
+	if err := untrackRelease(configMaps, "unknown", "web"); err != nil {
+		t.Fatal(err)
+	}
+
+	tracked, err := trackedReleases(configMaps, "shop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := map[string]bool{"api": true}; !reflect.DeepEqual(tracked, expected) {
+		t.Errorf("expected %v to be tracked, got %v", expected, tracked)
+	}
+	tracked, err = trackedReleases(configMaps, "blog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := map[string]bool{"blog": true}; !reflect.DeepEqual(tracked, expected) {
+		t.Errorf("expected %v to be tracked, got %v", expected, tracked)
+	}
+}
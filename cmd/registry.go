@@ -0,0 +1,148 @@
+/*
+Copyright © 2020 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/containerd/containerd/remotes"
+	dockerauth "github.com/deislabs/oras/pkg/auth/docker"
+	orascontent "github.com/deislabs/oras/pkg/content"
+	"github.com/deislabs/oras/pkg/oras"
+	"github.com/pkg/errors"
+
+	"helm.sh/helm/v3/pkg/helmpath"
+)
+
+const (
+	// ociScheme is the prefix of charts stored in an OCI registry
+	ociScheme = "oci://"
+
+	// Media types of the helm chart manifest config and content layer
+	helmChartConfigMediaType       = "application/vnd.cncf.helm.config.v1+json"
+	helmChartContentLayerMediaType = "application/tar+gzip"
+)
+
+var (
+	// registryPlainHTTP allows to talk to registries without TLS, e.g. a local registry:2
+	registryPlainHTTP bool
+)
+
+type ociReference struct {
+	Repo string
+	Tag  string
+}
+
+// parseOCIReference splits oci://registry/path/chart:version into repo and tag
+func parseOCIReference(ref string, version string) (*ociReference, error) {
+	s := strings.TrimPrefix(ref, ociScheme)
+	r := &ociReference{Repo: s}
+	// A colon after the last slash separates the tag, others belong to the host port
+	if i := strings.LastIndex(s, ":"); i > strings.LastIndex(s, "/") {
+		r.Repo, r.Tag = s[:i], s[i+1:]
+	}
+	if r.Tag == "" {
+		r.Tag = version
+	}
+	if r.Tag == "" {
+		return nil, errors.Errorf("chart version is required for %s, use oci://registry/chart:version or --version", ref)
+	}
+	if !strings.Contains(r.Repo, "/") {
+		return nil, errors.Errorf("invalid chart reference %s, expected oci://registry/path/chart:version", ref)
+	}
+	return r, nil
+}
+
+func (r *ociReference) FullName() string {
+	return r.Repo + ":" + r.Tag
+}
+
+func (r *ociReference) Hostname() string {
+	return strings.SplitN(r.Repo, "/", 2)[0]
+}
+
+// newRegistryResolver returns a resolver authenticated with the registry config
+func newRegistryResolver() (remotes.Resolver, error) {
+	client, err := dockerauth.NewClient(settings.RegistryConfig)
+	if err != nil {
+		return nil, err
+	}
+	return client.Resolver(context.Background(), http.DefaultClient, registryPlainHTTP)
+}
+
+// registryChartCache is the content-addressed cache of pulled charts
+func registryChartCache() string {
+	return helmpath.CachePath("registry", "charts")
+}
+
+// pullOCIChart downloads a chart from an OCI registry unless the same
+// manifest digest is already cached, and returns the path to the archive
+func pullOCIChart(ref string, version string) (string, error) {
+	r, err := parseOCIReference(ref, version)
+	if err != nil {
+		return "", err
+	}
+
+	resolver, err := newRegistryResolver()
+	if err != nil {
+		return "", err
+	}
+
+	ctx := context.Background()
+	_, manifest, err := resolver.Resolve(ctx, r.FullName())
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to resolve %s", r.FullName())
+	}
+
+	cached := filepath.Join(registryChartCache(), manifest.Digest.Encoded()+".tgz")
+	if _, err := os.Stat(cached); err == nil {
+		debug("We use cached chart \"%s\" for \"%s\"", cached, r.FullName())
+		return cached, nil
+	}
+
+	debug("We pull chart \"%s\"", r.FullName())
+	store := orascontent.NewMemoryStore()
+	_, layers, err := oras.Pull(ctx, resolver, r.FullName(), store,
+		oras.WithPullEmptyNameAllowed(),
+		oras.WithAllowedMediaTypes([]string{helmChartConfigMediaType, helmChartContentLayerMediaType}))
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to pull %s", r.FullName())
+	}
+
+	for _, layer := range layers {
+		if layer.MediaType != helmChartContentLayerMediaType {
+			continue
+		}
+		_, data, ok := store.Get(layer)
+		if !ok {
+			break
+		}
+		if err := os.MkdirAll(registryChartCache(), 0755); err != nil {
+			return "", err
+		}
+		if err := ioutil.WriteFile(cached, data, 0644); err != nil {
+			return "", err
+		}
+		return cached, nil
+	}
+	return "", errors.Errorf("%s does not contain a chart", r.FullName())
+}
@@ -0,0 +1,196 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	dockerauth "github.com/deislabs/oras/pkg/auth/docker"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh/terminal"
+
+	"helm.sh/helm/v3/cmd/helm/require"
+)
+
+const registryHelp = `
+This command consists of multiple subcommands to interact with OCI registries.
+
+Charts stored in a registry are deployed with references like
+oci://registry/path/chart:version.
+`
+
+const registryLoginDesc = `
+Authenticate to a remote registry.
+`
+
+const registryLogoutDesc = `
+Remove credentials stored for a remote registry.
+`
+
+func newRegistryCmd(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "registry login|logout",
+		Short: "Login to or logout from a registry",
+		Long:  registryHelp,
+		Args:  require.NoArgs,
+	}
+
+	flags := cmd.PersistentFlags()
+	settings.AddFlags(flags)
+
+	cmd.AddCommand(
+		newRegistryLoginCmd(os.Stdin, out),
+		newRegistryLogoutCmd(out),
+	)
+
+	return cmd
+}
+
+func newRegistryLoginCmd(in io.Reader, out io.Writer) *cobra.Command {
+	var usernameOpt, passwordOpt string
+	var passwordFromStdinOpt, insecureOpt bool
+
+	cmd := &cobra.Command{
+		Use:   "login [host]",
+		Short: "Login to a registry",
+		Long:  registryLoginDesc,
+		Args:  require.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			hostname := args[0]
+
+			username, password, err := getUsernamePassword(in, usernameOpt, passwordOpt, passwordFromStdinOpt)
+			if err != nil {
+				return err
+			}
+
+			return RunRegistryLogin(hostname, username, password, insecureOpt, out)
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringVarP(&usernameOpt, "username", "u", "", "registry username")
+	f.StringVarP(&passwordOpt, "password", "p", "", "registry password or identity token")
+	f.BoolVarP(&passwordFromStdinOpt, "password-stdin", "", false, "read password or identity token from stdin")
+	f.BoolVarP(&insecureOpt, "insecure", "", false, "allow connections to TLS registry without certs")
+
+	return cmd
+}
+
+func newRegistryLogoutCmd(out io.Writer) *cobra.Command {
+	return &cobra.Command{
+		Use:   "logout [host]",
+		Short: "Logout from a registry",
+		Long:  registryLogoutDesc,
+		Args:  require.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunRegistryLogout(args[0], out)
+		},
+	}
+}
+
+//Login to registry
+func RunRegistryLogin(hostname, username, password string, insecure bool, out io.Writer) error {
+
+	setLogger()
+	debug("We log in to registry \"%s\" as \"%s\"", hostname, username)
+	client, err := dockerauth.NewClient(settings.RegistryConfig)
+	if err != nil {
+		return err
+	}
+	if err := client.Login(context.Background(), hostname, username, password, insecure); err != nil {
+		return err
+	}
+	fmt.Fprintln(out, "Login succeeded")
+	return nil
+}
+
+//Logout from registry
+func RunRegistryLogout(hostname string, out io.Writer) error {
+
+	setLogger()
+	debug("We log out from registry \"%s\"", hostname)
+	client, err := dockerauth.NewClient(settings.RegistryConfig)
+	if err != nil {
+		return err
+	}
+	if err := client.Logout(context.Background(), hostname); err != nil {
+		return err
+	}
+	fmt.Fprintln(out, "Logout succeeded")
+	return nil
+}
+
+// getUsernamePassword reads the credentials from flags, stdin or a prompt
+func getUsernamePassword(in io.Reader, usernameOpt string, passwordOpt string, passwordFromStdinOpt bool) (string, string, error) {
+	var err error
+	username := usernameOpt
+	password := passwordOpt
+
+	if passwordFromStdinOpt {
+		passwordFromStdin, err := ioutil.ReadAll(in)
+		if err != nil {
+			return "", "", err
+		}
+		password = strings.TrimSuffix(string(passwordFromStdin), "\n")
+		password = strings.TrimSuffix(password, "\r")
+	} else if password == "" {
+		// The password is never echoed, without a terminal it comes from stdin
+		fd := int(os.Stdin.Fd())
+		if !terminal.IsTerminal(fd) {
+			return "", "", errors.New("password required, pass it with --password-stdin")
+		}
+		if username == "" {
+			if username, err = readLine(bufio.NewReader(in), "Username: "); err != nil {
+				return "", "", err
+			}
+		}
+		if password, err = readPassword(fd, "Password: "); err != nil {
+			return "", "", err
+		}
+		if password == "" {
+			return "", "", errors.New("password required")
+		}
+	}
+
+	return username, password, nil
+}
+
+func readLine(reader *bufio.Reader, prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	line, err := reader.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+func readPassword(fd int, prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	password, err := terminal.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	return string(password), nil
+}
@@ -0,0 +1,169 @@
+/*
+Copyright © 2020 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	orascontent "github.com/deislabs/oras/pkg/content"
+	"github.com/deislabs/oras/pkg/oras"
+	"github.com/docker/distribution/configuration"
+	"github.com/docker/distribution/registry/handlers"
+	_ "github.com/docker/distribution/registry/storage/driver/inmemory"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+)
+
+func TestParseOCIReference(t *testing.T) {
+	tests := []struct {
+		ref, version string
+		repo, tag    string
+		err          bool
+	}{
+		{ref: "oci://registry.example.com/charts/api:1.2.3", repo: "registry.example.com/charts/api", tag: "1.2.3"},
+		{ref: "oci://localhost:5000/charts/api:1.2.3", repo: "localhost:5000/charts/api", tag: "1.2.3"},
+		{ref: "oci://localhost:5000/charts/api", version: "1.2.3", repo: "localhost:5000/charts/api", tag: "1.2.3"},
+		{ref: "oci://registry.example.com/charts/api:1.2.3", version: "2.0.0", repo: "registry.example.com/charts/api", tag: "1.2.3"},
+		{ref: "oci://localhost:5000/charts/api", err: true},
+		{ref: "oci://api:1.2.3", err: true},
+	}
+	for _, tt := range tests {
+		r, err := parseOCIReference(tt.ref, tt.version)
+		if tt.err {
+			if err == nil {
+				t.Errorf("expected %s to be invalid", tt.ref)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.ref, err)
+			continue
+		}
+		if r.Repo != tt.repo || r.Tag != tt.tag {
+			t.Errorf("expected %s to be %s:%s, got %s:%s", tt.ref, tt.repo, tt.tag, r.Repo, r.Tag)
+		}
+	}
+}
+
+// startRegistry runs an in-memory registry and returns its host
+func startRegistry(t *testing.T) (string, func()) {
+	t.Helper()
+	config := &configuration.Configuration{}
+	config.Storage = map[string]configuration.Parameters{"inmemory": map[string]interface{}{}}
+	config.HTTP.Secret = "lincos"
+	server := httptest.NewServer(handlers.NewApp(context.Background(), config))
+	return strings.TrimPrefix(server.URL, "http://"), server.Close
+}
+
+// pushChart pushes a chart archive the way 'helm chart push' does
+func pushChart(t *testing.T, ref string, ch *chart.Chart) {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "lincos-push")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	archive, err := chartutil.Save(ch, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resolver, err := newRegistryResolver()
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := orascontent.NewMemoryStore()
+	config := store.Add("", helmChartConfigMediaType, []byte(`{"name":"`+ch.Metadata.Name+`","version":"`+ch.Metadata.Version+`"}`))
+	layer := store.Add("", helmChartContentLayerMediaType, data)
+	if _, err := oras.Push(context.Background(), resolver, ref, store, []ocispec.Descriptor{layer}, oras.WithConfig(config), oras.WithNameValidation(nil)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPullOCIChart(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lincos-registry")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer os.Setenv("HELM_CACHE_HOME", os.Getenv("HELM_CACHE_HOME"))
+	os.Setenv("HELM_CACHE_HOME", filepath.Join(dir, "cache"))
+	defer func(config string, plainHTTP bool) {
+		settings.RegistryConfig, registryPlainHTTP = config, plainHTTP
+	}(settings.RegistryConfig, registryPlainHTTP)
+	settings.RegistryConfig = filepath.Join(dir, "registry.json")
+	registryPlainHTTP = true
+
+	host, stop := startRegistry(t)
+	defer stop()
+	pushChart(t, host+"/charts/api:1.2.3", &chart.Chart{
+		Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: "api", Version: "1.2.3"},
+	})
+
+	cp, err := pullOCIChart(ociScheme+host+"/charts/api", "1.2.3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(cp, registryChartCache()) {
+		t.Errorf("expected the chart in the cache %s, got %s", registryChartCache(), cp)
+	}
+	ch, err := loader.Load(cp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ch.Metadata.Name != "api" || ch.Metadata.Version != "1.2.3" {
+		t.Errorf("expected chart api 1.2.3, got %s %s", ch.Metadata.Name, ch.Metadata.Version)
+	}
+
+	// The same manifest digest is served from the cache
+	if err := ioutil.WriteFile(cp, []byte("cached"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cached, err := pullOCIChart(ociScheme+host+"/charts/api:1.2.3", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := ioutil.ReadFile(cached); cached != cp || string(data) != "cached" {
+		t.Errorf("expected the cached chart %s, got %s", cp, cached)
+	}
+
+	if _, err := pullOCIChart(ociScheme+host+"/charts/api:9.9.9", ""); err == nil {
+		t.Error("expected an unknown tag to fail")
+	}
+}
+
+func TestLocateOCIChartVerify(t *testing.T) {
+	c := &action.ChartPathOptions{Verify: true}
+	_, _, _, err := locateReleaseChart(c, "oci://registry.example.com/charts/api:1.2.3", "default")
+	if err == nil || !strings.Contains(err.Error(), "have no provenance file") {
+		t.Errorf("expected --verify to be rejected for OCI charts, got %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"helm.sh/helm/v3/cmd/helm/require"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/cli/output"
+	"helm.sh/helm/v3/pkg/release"
+)
+
+const releaseTestHelp = `
+The test command runs the tests for a release.
+
+The argument this command takes is the name of a deployed release.
+The tests to be run are defined in the chart that was installed.
+`
+
+func newReleaseTestCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
+	client := action.NewReleaseTesting(cfg)
+	var outfmt output.Format
+	var outputLogs bool
+	cmd := &cobra.Command{
+		Use:               "test [release name]",
+		Short:             "Run tests for a release",
+		Long:              releaseTestHelp,
+		Args:              require.ExactArgs(1),
+		ValidArgsFunction: compReleaseArg(cfg),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			rel, runErr := RunReleaseTest(args[0], cfg, client)
+			// We only return an error if we weren't even able to get the
+			// release, otherwise we keep going so we can print status and logs
+			// if requested
+			if runErr != nil && rel == nil {
+				return runErr
+			}
+
+			if err := outfmt.Write(out, &statusPrinter{rel, settings.Debug, false, nil}); err != nil {
+				return err
+			}
+
+			if outputLogs {
+				// Print a newline to stdout to separate the output
+				fmt.Fprintln(out)
+				if err := client.GetPodLogs(out, rel); err != nil {
+					return err
+				}
+			}
+
+			return runErr
+		},
+	}
+
+	f := cmd.Flags()
+	f.DurationVar(&client.Timeout, "timeout", 300*time.Second, "time to wait for any individual Kubernetes operation (like Jobs for hooks)")
+	f.BoolVar(&outputLogs, "logs", false, "dump the logs from test pods (this runs after all tests are complete, but before any cleanup)")
+	bindOutputFlag(cmd, &outfmt)
+
+	return cmd
+}
+
+//Test release
+func RunReleaseTest(name string, cfg *action.Configuration, client *action.ReleaseTesting) (*release.Release, error) {
+
+	setLogger()
+	if err := initActionConfig(cfg); err != nil {
+		return nil, err
+	}
+	return testRelease(client, name)
+}
+
+// testRelease runs the test hooks of an already initialized release
+func testRelease(client *action.ReleaseTesting, name string) (*release.Release, error) {
+	debug("We run tests of release \"%s\"", name)
+	client.Namespace = settings.Namespace()
+	return client.Run(name)
+}
@@ -0,0 +1,74 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"helm.sh/helm/v3/cmd/helm/require"
+	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+var repoHelp = `
+This command consists of multiple subcommands to interact with chart repositories.
+It can be used to add, remove, list, and update chart repositories.
+
+Repositories are stored in the file given by '--repository-config' and their
+index files are downloaded into '--repository-cache'.
+`
+
+func newRepoCmd(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "repo add|remove|list|update [ARGS]",
+		Short: "Add, list, remove and update chart repositories",
+		Long:  repoHelp,
+		Args:  require.NoArgs,
+	}
+
+	flags := cmd.PersistentFlags()
+	settings.AddFlags(flags)
+
+	cmd.AddCommand(
+		newRepoAddCmd(out),
+		newRepoListCmd(out),
+		newRepoUpdateCmd(out),
+		newRepoRemoveCmd(out),
+	)
+
+	return cmd
+}
+
+// newChartRepository creates a repository client caching into the repository cache
+func newChartRepository(entry *repo.Entry) (*repo.ChartRepository, error) {
+	r, err := repo.NewChartRepository(entry, getter.All(settings))
+	if err != nil {
+		return nil, err
+	}
+	if settings.RepositoryCache != "" {
+		r.CachePath = settings.RepositoryCache
+	}
+	return r, nil
+}
+
+func isNotExist(err error) bool {
+	return os.IsNotExist(errors.Cause(err))
+}
@@ -0,0 +1,106 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"io"
+	"strings"
+
+	"github.com/gosuri/uitable"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"helm.sh/helm/v3/cmd/helm/require"
+	"helm.sh/helm/v3/pkg/cli/output"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+func newRepoListCmd(out io.Writer) *cobra.Command {
+	var outfmt output.Format
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List chart repositories",
+		Args:    require.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			f, err := repo.LoadFile(settings.RepositoryConfig)
+			if isNotExist(err) || (len(f.Repositories) == 0 && !(outfmt == output.JSON || outfmt == output.YAML)) {
+				return errors.New("no repositories to show")
+			}
+			return outfmt.Write(out, newRepoListWriter(f.Repositories))
+		},
+	}
+
+	bindOutputFlag(cmd, &outfmt)
+
+	return cmd
+}
+
+type repositoryElement struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+type repoListWriter struct {
+	repos []repositoryElement
+}
+
+func newRepoListWriter(repos []*repo.Entry) *repoListWriter {
+	// Initialize the array so no results returns an empty array instead of null
+	elements := make([]repositoryElement, 0, len(repos))
+	for _, re := range repos {
+		elements = append(elements, repositoryElement{Name: re.Name, URL: re.URL})
+	}
+	return &repoListWriter{elements}
+}
+
+func (r *repoListWriter) WriteTable(out io.Writer) error {
+	table := uitable.New()
+	table.AddRow("NAME", "URL")
+	for _, re := range r.repos {
+		table.AddRow(re.Name, re.URL)
+	}
+	return output.EncodeTable(out, table)
+}
+
+func (r *repoListWriter) WriteJSON(out io.Writer) error {
+	return output.EncodeJSON(out, r.repos)
+}
+
+func (r *repoListWriter) WriteYAML(out io.Writer) error {
+	return output.EncodeYAML(out, r.repos)
+}
+
+// compListRepos provides dynamic auto-completion for repo names
+func compListRepos(prefix string, ignoredRepoNames []string) []string {
+	var rNames []string
+
+	f, err := repo.LoadFile(settings.RepositoryConfig)
+	if err == nil && len(f.Repositories) > 0 {
+		for _, re := range f.Repositories {
+			if strings.HasPrefix(re.Name, prefix) && !containsString(ignoredRepoNames, re.Name) {
+				rNames = append(rNames, re.Name)
+			}
+		}
+	}
+	return rNames
+}
+
+// compRepoArgs completes a list of distinct repo names
+func compRepoArgs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return compListRepos(toComplete, args), cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,118 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+const updateDesc = `
+Update gets the latest information about charts from the respective chart repositories.
+Information is cached locally, where it is used by commands like 'lincos helm deploy'.
+
+Pass repository names to update only those repositories.
+`
+
+var errNoRepositories = errors.New("no repositories found. You must add one before updating")
+
+func newRepoUpdateCmd(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "update [repo name]...",
+		Aliases:           []string{"up"},
+		Short:             "Update information of available charts locally from chart repositories",
+		Long:              updateDesc,
+		ValidArgsFunction: compRepoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunRepoUpdate(args, out)
+		},
+	}
+	return cmd
+}
+
+//Update chart repository indexes
+func RunRepoUpdate(names []string, out io.Writer) error {
+
+	setLogger()
+	f, err := repo.LoadFile(settings.RepositoryConfig)
+	if isNotExist(err) || len(f.Repositories) == 0 {
+		return errNoRepositories
+	}
+
+	var repos []*repo.ChartRepository
+	for _, name := range names {
+		if !f.Has(name) {
+			return errors.Errorf("no repo named %q found", name)
+		}
+	}
+	for _, cfg := range f.Repositories {
+		if len(names) > 0 && !containsString(names, cfg.Name) {
+			continue
+		}
+		r, err := newChartRepository(cfg)
+		if err != nil {
+			return err
+		}
+		repos = append(repos, r)
+	}
+
+	return updateCharts(repos, out)
+}
+
+func updateCharts(repos []*repo.ChartRepository, out io.Writer) error {
+	fmt.Fprintln(out, "Hang tight while we grab the latest from your chart repositories...")
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	failed := 0
+	for _, re := range repos {
+		wg.Add(1)
+		go func(re *repo.ChartRepository) {
+			defer wg.Done()
+			_, err := re.DownloadIndexFile()
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				failed++
+				fmt.Fprintf(out, "...Unable to get an update from the %q chart repository (%s):\n\t%s\n", re.Config.Name, re.Config.URL, err)
+			} else {
+				fmt.Fprintf(out, "...Successfully got an update from the %q chart repository\n", re.Config.Name)
+			}
+		}(re)
+	}
+	wg.Wait()
+
+	if failed > 0 {
+		return errors.Errorf("%d of %d repositories failed to update", failed, len(repos))
+	}
+	fmt.Fprintln(out, "Update Complete.")
+	return nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
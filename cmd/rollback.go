@@ -0,0 +1,100 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/cli/output"
+	"helm.sh/helm/v3/pkg/release"
+)
+
+const rollbackDesc = `
+This command rolls back a release to a previous revision.
+
+The first argument of the rollback command is the name of a release, and the
+second is a revision (version) number. If this argument is omitted, it will
+roll back to the previous release.
+`
+
+func newRollbackCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
+	client := action.NewRollback(cfg)
+	var outfmt output.Format
+	cmd := &cobra.Command{
+		Use:               "rollback [release name] [revision]",
+		Short:             "Roll back a release to a previous revision",
+		Long:              rollbackDesc,
+		Args:              cobra.RangeArgs(1, 2),
+		ValidArgsFunction: compReleaseArg(cfg),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			rel, err := RunRollback(args, cfg, client)
+			if err != nil {
+				return err
+			}
+			return outfmt.Write(out, &statusPrinter{rel, settings.Debug, false, nil})
+		},
+	}
+
+	addRollbackFlags(cmd.Flags(), client)
+	bindOutputFlag(cmd, &outfmt)
+
+	return cmd
+}
+
+func addRollbackFlags(f *pflag.FlagSet, client *action.Rollback) {
+	f.BoolVar(&client.Force, "force", false, "force resource update through delete/recreate if needed")
+	f.BoolVar(&client.DisableHooks, "no-hooks", false, "prevent hooks from running during rollback")
+	f.DurationVar(&client.Timeout, "timeout", 300*time.Second, "time to wait for any individual Kubernetes operation (like Jobs for hooks)")
+	f.BoolVar(&client.Wait, "wait", false, "if set, will wait until all Pods, PVCs, Services, and minimum number of Pods of a Deployment, StatefulSet, or ReplicaSet are in a ready state before marking the release as successful. It will wait for as long as --timeout")
+	f.BoolVar(&client.CleanupOnFail, "cleanup-on-fail", false, "allow deletion of new resources created in this rollback when rollback fails")
+}
+
+// Roll back release to the given or previous revision
+func RunRollback(args []string, cfg *action.Configuration, client *action.Rollback) (*release.Release, error) {
+
+	setLogger()
+	if err := initActionConfig(cfg); err != nil {
+		return nil, err
+	}
+
+	name := args[0]
+	if len(args) > 1 {
+		ver, err := strconv.Atoi(args[1])
+		if err != nil {
+			return nil, errors.Wrap(err, "could not convert revision to a number")
+		}
+		client.Version = ver
+	}
+	debug("We roll back release \"%s\" to revision %d", name, client.Version)
+
+	if err := client.Run(name); err != nil {
+		return nil, err
+	}
+
+	statusHelmChart, err := NewStatus(cfg, name)
+	if err != nil {
+		return nil, err
+	}
+	return statusHelmChart.InfoStatus()
+}
@@ -35,7 +35,20 @@ func newRootCmd(out io.Writer, args []string) (*cobra.Command, error) {
 	// Add subcommands
 	cmd.AddCommand(
 		newHelmInitCmd(out),
+		newRepoCmd(out),
+		newRegistryCmd(out),
+		newBundleCmd(out),
+		newChartCmd(out),
+		newDependencyCmd(out),
+		newSearchCmd(out),
+		newLockCmd(out),
+		newPlanCmd(out),
+		newApplyCmd(os.Stdin, out),
+		newDiffReleasesCmd(out),
+		newDestroyCmd(os.Stdin, out),
+		newCompletionCmd(out),
 	)
+	registerSettingsCompletion(cmd)
 	return cmd, nil
 }
 
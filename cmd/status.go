@@ -1,10 +1,27 @@
 package cmd
 
 import (
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"helm.sh/helm/v3/cmd/helm/require"
 	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/cli/output"
 	"helm.sh/helm/v3/pkg/release"
 )
 
+var statusHelp = `
+This command shows the status of a named release.
+The status consists of:
+- last deployment time
+- k8s namespace in which the release lives
+- state of the release
+- revision of the release
+- details on last test suite run, if applicable
+- additional notes provided by the chart
+`
+
 type Status struct {
 	statusClient *action.Status
 	releaseName  string
@@ -19,7 +36,62 @@ func NewStatus(cfg *action.Configuration, releaseName string) (*Status, error) {
 	}, nil
 }
 
+// WithRevision makes the status look up the given revision instead of the latest one
+func (status *Status) WithRevision(revision int) *Status {
+	status.statusClient.Version = revision
+	return status
+}
+
 func (status *Status) InfoStatus() (*release.Release, error) {
 	results, err := status.statusClient.Run(status.releaseName)
 	return results, err
 }
+
+func newStatusCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
+	var outfmt output.Format
+	var revision int
+	var showDescription bool
+	cmd := &cobra.Command{
+		Use:               "status [release name]",
+		Short:             "Display the status of the named release",
+		Long:              statusHelp,
+		Args:              require.ExactArgs(1),
+		ValidArgsFunction: compReleaseArg(cfg),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			rel, err := RunStatus(args[0], revision, cfg)
+			if err != nil {
+				return err
+			}
+
+			// strip chart metadata from the output, it is only needed
+			// for computed values in debug mode
+			if !settings.Debug {
+				rel.Chart = nil
+			}
+
+			return outfmt.Write(out, &statusPrinter{rel, settings.Debug, showDescription, nil})
+		},
+	}
+
+	f := cmd.Flags()
+	f.IntVar(&revision, "revision", 0, "if set, display the status of the named release with revision")
+	f.BoolVar(&showDescription, "show-desc", false, "if set, display the description message of the named release")
+	bindOutputFlag(cmd, &outfmt)
+
+	return cmd
+}
+
+//Get release status
+func RunStatus(name string, revision int, cfg *action.Configuration) (*release.Release, error) {
+
+	setLogger()
+	if err := initActionConfig(cfg); err != nil {
+		return nil, err
+	}
+
+	statusHelmChart, err := NewStatus(cfg, name)
+	if err != nil {
+		return nil, err
+	}
+	return statusHelmChart.WithRevision(revision).InfoStatus()
+}
@@ -0,0 +1,257 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"helm.sh/helm/v3/cmd/helm/require"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/cli/values"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/releaseutil"
+)
+
+const templateDesc = `
+Render chart templates locally and display the output.
+
+Any values that would normally be looked up or retrieved in-cluster will be
+faked locally, so no cluster access is needed. Use '--api-versions' and
+'--kube-version' to override the capabilities the chart is rendered against.
+`
+
+type templateOptions struct {
+	showFiles   []string
+	includeCrds bool
+	extraAPIs   []string
+	kubeVersion string
+}
+
+func newTemplateCmd(cfg *action.Configuration, out io.Writer) *cobra.Command {
+	client := action.NewInstall(cfg)
+	valueOpts := &values.Options{}
+	opts := &templateOptions{}
+	cmd := &cobra.Command{
+		Use:   "template [release name] [chart path|chart name]",
+		Short: "Locally render templates",
+		Long:  templateDesc,
+		Args:  require.MinimumNArgs(1),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			// The release name is a new one, only the chart can be completed
+			if len(args) == 1 {
+				return compListCharts(toComplete, true)
+			}
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			clearDefaultKeyring(cmd, &client.ChartPathOptions)
+			rel, err := RunTemplate(args, cfg, client, opts, valueOpts, out)
+			if err != nil && !settings.Debug {
+				if rel != nil {
+					return fmt.Errorf("%w\n\nUse --debug flag to render out invalid YAML", err)
+				}
+				return err
+			}
+			// With --debug the YAML is printed even if it is not valid,
+			// the error is still returned afterwards.
+			if rel != nil {
+				if werr := writeTemplate(out, rel, client, opts.showFiles); werr != nil {
+					return werr
+				}
+			}
+			return err
+		},
+	}
+
+	f := cmd.Flags()
+	addChartPathOptionsFlags(f, &client.ChartPathOptions)
+	bindVersionCompletion(cmd, 1)
+	addValueOptionsFlags(f, valueOpts)
+	f.BoolVar(&client.DisableHooks, "no-hooks", false, "prevent hooks from being rendered")
+	f.BoolVar(&client.DependencyUpdate, "dependency-update", false, "run helm dependency update before rendering the chart")
+	f.StringArrayVarP(&opts.showFiles, "show-only", "s", []string{}, "only show manifests rendered from the given templates")
+	f.StringVar(&client.OutputDir, "output-dir", "", "writes the executed templates to files in output-dir instead of stdout")
+	f.BoolVar(&opts.includeCrds, "include-crds", false, "include CRDs in the templated output")
+	f.StringArrayVarP(&opts.extraAPIs, "api-versions", "a", []string{}, "Kubernetes api versions used for Capabilities.APIVersions")
+	f.StringVar(&opts.kubeVersion, "kube-version", "", "Kubernetes version used for Capabilities.KubeVersion")
+	bindPostRenderFlag(cmd, &client.PostRenderer)
+
+	return cmd
+}
+
+//Render release templates without a cluster
+func RunTemplate(
+	args []string,
+	cfg *action.Configuration,
+	client *action.Install,
+	opts *templateOptions,
+	valueOpts *values.Options,
+	out io.Writer,
+) (*release.Release, error) {
+
+	setLogger()
+	if err := initActionConfig(cfg); err != nil {
+		return nil, err
+	}
+
+	// Client only installs render against the default capabilities and add
+	// --api-versions to them, render against a copy for this run only
+	caps := *chartutil.DefaultCapabilities
+	caps.APIVersions = append(chartutil.VersionSet{}, caps.APIVersions...)
+	if opts.kubeVersion != "" {
+		kubeVersion, err := parseKubeVersion(opts.kubeVersion)
+		if err != nil {
+			return nil, err
+		}
+		caps.KubeVersion = *kubeVersion
+	}
+	defer func(defaults *chartutil.Capabilities) {
+		chartutil.DefaultCapabilities = defaults
+	}(chartutil.DefaultCapabilities)
+	chartutil.DefaultCapabilities = &caps
+
+	client.DryRun = true
+	client.Replace = true // Skip the name check
+	client.ClientOnly = true
+	client.APIVersions = chartutil.VersionSet(opts.extraAPIs)
+	client.IncludeCRDs = opts.includeCrds
+
+	name, chart, err := client.NameAndChart(args)
+	if err != nil {
+		return nil, err
+	}
+	debug("We render chart \"%s\" for release \"%s\"", chart, name)
+
+	rel, _, err := RunInstall(client, cfg, name, chart, valueOpts, out)
+	return rel, err
+}
+
+// parseKubeVersion converts a version like v1.18.4 into capabilities
+func parseKubeVersion(version string) (*chartutil.KubeVersion, error) {
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid kube version %q", version)
+	}
+	return &chartutil.KubeVersion{
+		Version: "v" + v.String(),
+		Major:   strconv.FormatUint(v.Major(), 10),
+		Minor:   strconv.FormatUint(v.Minor(), 10),
+	}, nil
+}
+
+// writeTemplate prints the rendered manifests or writes hooks to the output dir
+func writeTemplate(out io.Writer, rel *release.Release, client *action.Install, showFiles []string) error {
+	var manifests bytes.Buffer
+	fmt.Fprintln(&manifests, strings.TrimSpace(rel.Manifest))
+	if !client.DisableHooks {
+		fileWritten := make(map[string]bool)
+		for _, m := range rel.Hooks {
+			if client.OutputDir == "" {
+				fmt.Fprintf(&manifests, "---\n# Source: %s\n%s\n", m.Path, m.Manifest)
+				continue
+			}
+			if err := writeToFile(out, client.OutputDir, m.Path, m.Manifest, fileWritten[m.Path]); err != nil {
+				return err
+			}
+			fileWritten[m.Path] = true
+		}
+	}
+
+	if len(showFiles) == 0 {
+		fmt.Fprintf(out, "%s", manifests.String())
+		return nil
+	}
+
+	// This is necessary to ensure consistent manifest ordering when using --show-only
+	// with globs or directory names.
+	splitManifests := releaseutil.SplitManifests(manifests.String())
+	manifestsKeys := make([]string, 0, len(splitManifests))
+	for k := range splitManifests {
+		manifestsKeys = append(manifestsKeys, k)
+	}
+	sort.Sort(releaseutil.BySplitManifestsOrder(manifestsKeys))
+
+	manifestNameRegex := regexp.MustCompile("# Source: [^/]+/(.+)")
+	var manifestsToRender []string
+	for _, f := range showFiles {
+		missing := true
+		// Use linux-style filepath separators to unify user's input path
+		f = filepath.ToSlash(f)
+		for _, manifestKey := range manifestsKeys {
+			manifest := splitManifests[manifestKey]
+			submatch := manifestNameRegex.FindStringSubmatch(manifest)
+			if len(submatch) == 0 {
+				continue
+			}
+			// if the filepath provided matches a manifest path in the
+			// chart, render that manifest
+			if matched, _ := filepath.Match(f, submatch[1]); !matched {
+				continue
+			}
+			manifestsToRender = append(manifestsToRender, manifest)
+			missing = false
+		}
+		if missing {
+			return fmt.Errorf("could not find template %s in chart", f)
+		}
+	}
+	for _, m := range manifestsToRender {
+		fmt.Fprintf(out, "---\n%s\n", m)
+	}
+	return nil
+}
+
+func writeToFile(out io.Writer, outputDir string, name string, data string, append bool) error {
+	outfileName := strings.Join([]string{outputDir, name}, string(filepath.Separator))
+
+	if err := os.MkdirAll(path.Dir(outfileName), 0755); err != nil {
+		return err
+	}
+
+	var f *os.File
+	var err error
+	if append {
+		f, err = os.OpenFile(outfileName, os.O_APPEND|os.O_WRONLY, 0600)
+	} else {
+		f, err = os.Create(outfileName)
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err = f.WriteString(fmt.Sprintf("---\n# Source: %s\n%s\n", name, data)); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "wrote %s\n", outfileName)
+	return nil
+}
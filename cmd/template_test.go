@@ -0,0 +1,75 @@
+/*
+Copyright © 2020 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/cli/values"
+)
+
+func TestRunTemplateKubeVersion(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lincos-template")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.MkdirAll(filepath.Join(dir, "templates"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"Chart.yaml":          "apiVersion: v2\nname: api\nversion: 1.0.0\n",
+		"templates/caps.yaml": "kind: ConfigMap\ndata:\n  kube: {{ .Capabilities.KubeVersion.Version }}\n  api: {{ .Capabilities.APIVersions.Has \"lincos.example.com/v1\" | quote }}\n",
+	}
+	for name, data := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	os.Setenv("HELM_DRIVER", "memory")
+	defer os.Unsetenv("HELM_DRIVER")
+
+	defaults := *chartutil.DefaultCapabilities
+	render := func(opts *templateOptions) string {
+		cfg := new(action.Configuration)
+		client := action.NewInstall(cfg)
+		rel, err := RunTemplate([]string{"api", dir}, cfg, client, opts, &values.Options{}, ioutil.Discard)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return rel.Manifest
+	}
+
+	manifest := render(&templateOptions{kubeVersion: "1.16.2", extraAPIs: []string{"lincos.example.com/v1"}})
+	if !strings.Contains(manifest, "kube: v1.16.2") || !strings.Contains(manifest, `api: "true"`) {
+		t.Errorf("expected the given kube and API versions to be rendered, got\n%s", manifest)
+	}
+
+	manifest = render(&templateOptions{})
+	if !strings.Contains(manifest, "kube: "+defaults.KubeVersion.Version) || !strings.Contains(manifest, `api: "false"`) {
+		t.Errorf("expected the default kube and API versions after an override, got\n%s", manifest)
+	}
+	if chartutil.DefaultCapabilities.KubeVersion != defaults.KubeVersion || len(chartutil.DefaultCapabilities.APIVersions) != len(defaults.APIVersions) {
+		t.Errorf("expected the default capabilities to be left unchanged")
+	}
+}
@@ -0,0 +1,211 @@
+/*
+Copyright The Helm Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/yaml"
+
+	"helm.sh/helm/v3/cmd/helm/require"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/releaseutil"
+)
+
+// resourcePolicyAnnotation tells helm to keep an object on uninstall
+const resourcePolicyAnnotation = "helm.sh/resource-policy"
+
+const uninstallDesc = `
+This command takes a release name and uninstalls the release.
+
+It removes all of the resources associated with the last release of the chart
+as well as the release history, freeing it up for future use.
+
+Unless --yes is passed, the command asks for confirmation before anything is
+deleted. Use the '--dry-run' flag to see which resources would be deleted.
+`
+
+func newUninstallCmd(cfg *action.Configuration, in io.Reader, out io.Writer) *cobra.Command {
+	client := action.NewUninstall(cfg)
+	var yes bool
+	cmd := &cobra.Command{
+		Use:               "uninstall [release name]",
+		Short:             "Uninstall a release",
+		Long:              uninstallDesc,
+		Args:              require.ExactArgs(1),
+		ValidArgsFunction: compReleaseArg(cfg),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !client.DryRun && !yes {
+				ok, err := confirm(in, out, fmt.Sprintf("Uninstall release \"%s\" from namespace \"%s\"?", args[0], settings.Namespace()))
+				if err != nil {
+					return err
+				}
+				if !ok {
+					return errors.New("uninstall aborted")
+				}
+			}
+			res, err := RunUninstall(args[0], cfg, client)
+			if err != nil {
+				return err
+			}
+			return printUninstall(out, res, client.DryRun)
+		},
+	}
+
+	addUninstallFlags(cmd.Flags(), client)
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "do not ask for confirmation before uninstalling")
+
+	return cmd
+}
+
+func addUninstallFlags(f *pflag.FlagSet, client *action.Uninstall) {
+	f.BoolVar(&client.DryRun, "dry-run", false, "simulate an uninstall")
+	f.BoolVar(&client.DisableHooks, "no-hooks", false, "prevent hooks from running during uninstallation")
+	f.BoolVar(&client.KeepHistory, "keep-history", false, "remove all associated resources and mark the release as deleted, but retain the release history")
+	f.DurationVar(&client.Timeout, "timeout", 300*time.Second, "time to wait for any individual Kubernetes operation (like Jobs for hooks)")
+}
+
+//Uninstall release
+func RunUninstall(name string, cfg *action.Configuration, client *action.Uninstall) (*release.UninstallReleaseResponse, error) {
+
+	setLogger()
+	if err := initActionConfig(cfg); err != nil {
+		return nil, err
+	}
+	debug("We uninstall release \"%s\" from namespace \"%s\"", name, settings.Namespace())
+
+	return client.Run(name)
+}
+
+// printUninstall lists the resources that were (or would be) deleted
+func printUninstall(out io.Writer, res *release.UninstallReleaseResponse, dryRun bool) error {
+	if res == nil || res.Release == nil {
+		return nil
+	}
+
+	resources, err := releaseResources(res.Release, releaseutil.UninstallOrder)
+	if err != nil {
+		return err
+	}
+
+	// Helm reports kept resources by template name, which may render several
+	// objects, so each object is matched by kind, namespace and name
+	kept := map[string]bool{}
+	for _, m := range resources {
+		if keptByPolicy(m) {
+			kept[objectKey(m)] = true
+		}
+	}
+
+	if dryRun {
+		fmt.Fprintf(out, "release \"%s\" would be uninstalled\n", res.Release.Name)
+		fmt.Fprintln(out, "These resources would be deleted:")
+	} else {
+		fmt.Fprintf(out, "release \"%s\" uninstalled\n", res.Release.Name)
+		fmt.Fprintln(out, "These resources were deleted:")
+	}
+	for _, m := range resources {
+		if kept[objectKey(m)] {
+			continue
+		}
+		fmt.Fprintf(out, "  %s\n", resourceName(m))
+	}
+
+	if len(kept) > 0 {
+		if dryRun {
+			fmt.Fprintln(out, "These resources would be kept due to the resource policy:")
+		} else {
+			fmt.Fprintln(out, "These resources were kept due to the resource policy:")
+		}
+		for _, m := range resources {
+			if kept[objectKey(m)] {
+				fmt.Fprintf(out, "  %s\n", resourceName(m))
+			}
+		}
+	}
+	return nil
+}
+
+// keptByPolicy reports whether helm keeps an object on uninstall, the same
+// way helm filters the manifests to keep
+func keptByPolicy(m releaseutil.Manifest) bool {
+	if m.Head == nil || m.Head.Metadata == nil {
+		return false
+	}
+	policy, ok := m.Head.Metadata.Annotations[resourcePolicyAnnotation]
+	return ok && strings.ToLower(strings.TrimSpace(policy)) == "keep"
+}
+
+// objectKey identifies an object of a manifest by kind, namespace and name
+func objectKey(m releaseutil.Manifest) string {
+	var obj struct {
+		Kind     string `json:"kind"`
+		Metadata struct {
+			Namespace string `json:"namespace"`
+			Name      string `json:"name"`
+		} `json:"metadata"`
+	}
+	if err := yaml.Unmarshal([]byte(m.Content), &obj); err != nil {
+		return m.Name
+	}
+	return fmt.Sprintf("%s/%s/%s", obj.Kind, obj.Metadata.Namespace, obj.Metadata.Name)
+}
+
+// releaseResources splits the release manifest into Kubernetes objects sorted
+// by kind in the given order
+func releaseResources(rel *release.Release, order releaseutil.KindSortOrder) ([]releaseutil.Manifest, error) {
+	manifests := releaseutil.SplitManifests(rel.Manifest)
+	_, files, err := releaseutil.SortManifests(manifests, chartutil.DefaultVersionSet, order)
+	if err != nil {
+		return nil, errors.Wrap(err, "corrupted release record")
+	}
+	return files, nil
+}
+
+// resourceName formats a manifest as Kind/name
+func resourceName(m releaseutil.Manifest) string {
+	if m.Head == nil {
+		return m.Name
+	}
+	if m.Head.Metadata == nil {
+		return m.Head.Kind
+	}
+	return fmt.Sprintf("%s/%s", m.Head.Kind, m.Head.Metadata.Name)
+}
+
+// confirm asks a yes/no question and waits for the answer
+func confirm(in io.Reader, out io.Writer, question string) (bool, error) {
+	fmt.Fprintf(out, "%s [y/N]: ", question)
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	}
+	return false, nil
+}
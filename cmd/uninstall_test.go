@@ -0,0 +1,60 @@
+/*
+Copyright © 2020 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/release"
+)
+
+func TestPrintUninstallKeptPerObject(t *testing.T) {
+	manifest := `---
+# Source: app/templates/config.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+  annotations:
+    helm.sh/resource-policy: keep
+---
+# Source: app/templates/config.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cache
+`
+	res := &release.UninstallReleaseResponse{
+		Release: &release.Release{Name: "app", Manifest: manifest},
+		Info:    "app/templates/config.yaml\n",
+	}
+
+	var out bytes.Buffer
+	if err := printUninstall(&out, res, false); err != nil {
+		t.Fatal(err)
+	}
+	expected := `release "app" uninstalled
+These resources were deleted:
+  ConfigMap/cache
+These resources were kept due to the resource policy:
+  ConfigMap/settings
+`
+	if out.String() != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, out.String())
+	}
+}
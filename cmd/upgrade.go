@@ -18,7 +18,6 @@ package cmd
 
 import (
 	"helm.sh/helm/v3/pkg/action"
-	"helm.sh/helm/v3/pkg/chart/loader"
 	"helm.sh/helm/v3/pkg/cli/values"
 	"helm.sh/helm/v3/pkg/getter"
 	"helm.sh/helm/v3/pkg/release"
@@ -32,40 +31,42 @@ func RunUpgrade(
 	releaseName string,
 	chart string,
 	valueOpts *values.Options,
+	dependencyUpdate bool,
 	out io.Writer,
-) (*release.Release, error) {
+) (*release.Release, *provenanceReport, error) {
 	debug( "We use chart name for upgrade: %s", releaseName)
 
-	clientUpgrade.Namespace = settings.Namespace()
+	if clientUpgrade.Namespace == "" {
+		clientUpgrade.Namespace = settings.Namespace()
+	}
 	debug("RunInstall Namespace:", clientUpgrade.Namespace)
 
-	chartPath, err := clientUpgrade.ChartPathOptions.LocateChart(chart, settings)
+	chartPath, commit, report, err := locateReleaseChart(&clientUpgrade.ChartPathOptions, chart, clientUpgrade.Namespace)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	if commit != "" && !clientUpgrade.DryRun {
+		clientUpgrade.Description = gitDescription(clientUpgrade.Description, "Upgrade complete", commit)
 	}
 
 	debug( "CHART PATH:", chartPath)
 
 	vals, err := valueOpts.MergeValues(getter.All(settings))
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// Check chart dependencies to make sure all are present in /charts
-	ch, err := loader.Load(chartPath)
+	ch, err := loadChart(chartPath, &clientUpgrade.ChartPathOptions, dependencyUpdate, out)
 	if err != nil {
-		return nil, err
-	}
-	if req := ch.Metadata.Dependencies; req != nil {
-		if err := action.CheckDependencies(ch, req); err != nil {
-			return nil, err
-		}
+		return nil, nil, err
 	}
 
 	if ch.Metadata.Deprecated {
 		warning("This chart is deprecated")
 	}
 
-	return clientUpgrade.Run(releaseName, ch, vals)
+	rel, err := clientUpgrade.Run(releaseName, ch, vals)
+	return rel, report, err
 
 }
@@ -3,10 +3,25 @@ module github.com/alexey-pankratyev/lincos
 go 1.15
 
 require (
+	github.com/Masterminds/semver/v3 v3.1.0
+	github.com/Masterminds/sprig/v3 v3.1.0
+	github.com/containerd/containerd v1.3.4
+	github.com/deislabs/oras v0.8.1
+	github.com/docker/distribution v2.7.1+incompatible
+	github.com/fatih/color v1.7.0
+	github.com/gosuri/uitable v0.0.4
+	github.com/opencontainers/image-spec v1.0.1
 	github.com/pkg/errors v0.9.1
+	github.com/pmezard/go-difflib v1.0.0
 	github.com/sirupsen/logrus v1.6.0
 	github.com/spf13/cobra v1.0.0
 	github.com/spf13/pflag v1.0.5
 	github.com/spf13/viper v1.7.1
+	golang.org/x/crypto v0.0.0-20200622213623-75b288015ac9
 	helm.sh/helm/v3 v3.3.3
+	k8s.io/api v0.18.8
+	k8s.io/apimachinery v0.18.8
+	k8s.io/cli-runtime v0.18.8
+	k8s.io/client-go v0.18.8
+	sigs.k8s.io/yaml v1.2.0
 )